@@ -1,6 +1,10 @@
 package binding
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
 
 const (
 	MIMEJSON              = "application/json"
@@ -10,6 +14,9 @@ const (
 	MIMEPOSTForm          = "application/x-www-form-urlencoded"
 	MIMEMultipartPOSTForm = "multipart/form-data"
 	MIMEYAML              = "application/x-yaml"
+	MIMEMSGPACK           = "application/x-msgpack"
+	MIMEMSGPACK2          = "application/msgpack"
+	MIMECSV               = "text/csv"
 )
 
 var (
@@ -19,32 +26,89 @@ var (
 	Form          = formBinding{}
 	FormPost      = formPostBinding{}
 	FormMultipart = multipartBinding{}
+	MsgPack       = msgpackBinding{}
+	CSV           = csvBinding{}
 )
 
 type Binder interface {
 	Bind(*http.Request, interface{}) error
 }
 
+// BodyBinder 可以直接从已读取的请求体中解析数据，
+// 配合 Context.ShouldBindBodyWith 使用，使请求体可以被多次解析
+type BodyBinder interface {
+	Binder
+	BindBody([]byte, interface{}) error
+}
+
+// binders MIME 类型到 Binder 的注册表，Default 根据请求的 Content-Type 从中查找 Binder
+var (
+	bindersMu sync.RWMutex
+	binders   = map[string]Binder{
+		MIMEJSON:              JSON,
+		MIMEXML:               XML,
+		MIMEXML2:              XML,
+		MIMEYAML:              YAML,
+		MIMEPlain:             Form,
+		MIMEPOSTForm:          FormPost,
+		MIMEMultipartPOSTForm: FormMultipart,
+		MIMEMSGPACK:           MsgPack,
+		MIMEMSGPACK2:          MsgPack,
+		MIMECSV:               CSV,
+	}
+)
+
+// Register 注册 MIME 类型对应的 Binder，已存在时会覆盖，
+// 可用于支持 CBOR、MessagePack 或 application/vnd.*+json 等格式
+func Register(mime string, b Binder) {
+	if b == nil {
+		panic("binding: Register binder is nil")
+	}
+
+	bindersMu.Lock()
+	defer bindersMu.Unlock()
+	binders[normalizeMIME(mime)] = b
+}
+
+// Lookup 查找 MIME 类型对应的 Binder。没有注册时，
+// 带有 "+json"、"+xml"、"+yaml" 后缀的类型（如 application/vnd.api+json）会使用对应格式的 Binder
+func Lookup(mime string) (Binder, bool) {
+	mime = normalizeMIME(mime)
+
+	bindersMu.RLock()
+	b, ok := binders[mime]
+	bindersMu.RUnlock()
+	if ok {
+		return b, true
+	}
+
+	switch {
+	case strings.HasSuffix(mime, "+json"):
+		return JSON, true
+	case strings.HasSuffix(mime, "+xml"):
+		return XML, true
+	case strings.HasSuffix(mime, "+yaml"):
+		return YAML, true
+	}
+	return nil, false
+}
+
 func Default(method, contentType string) Binder {
 	// 当请求的 Method 是时 GET 时，此时解析的是 URL 上的参数
 	if method == http.MethodGet {
 		return Form
 	}
 
-	switch contentType {
-	case MIMEJSON:
-		return JSON
-	case MIMEXML, MIMEXML2:
-		return XML
-	case MIMEYAML:
-		return YAML
-	case MIMEPlain:
-		return Form
-	case MIMEPOSTForm:
-		return FormPost
-	case MIMEMultipartPOSTForm:
-		return FormMultipart
-	default:
-		return Form
+	if b, ok := Lookup(contentType); ok {
+		return b
+	}
+	return Form
+}
+
+// normalizeMIME 去掉参数（如 "; charset=utf-8"）并转换为小写
+func normalizeMIME(mime string) string {
+	if i := strings.IndexByte(mime, ';'); i >= 0 {
+		mime = mime[:i]
 	}
+	return strings.ToLower(strings.TrimSpace(mime))
 }
@@ -0,0 +1,384 @@
+package binding
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type plainBinding struct{}
+
+func (plainBinding) Bind(req *http.Request, obj interface{}) error {
+	return nil
+}
+
+func TestDefault(t *testing.T) {
+	assert.Equal(t, Form, Default(http.MethodGet, MIMEJSON))
+	assert.Equal(t, JSON, Default(http.MethodPost, MIMEJSON))
+	assert.Equal(t, JSON, Default(http.MethodPost, "application/json; charset=utf-8"))
+	assert.Equal(t, XML, Default(http.MethodPost, MIMEXML2))
+	assert.Equal(t, FormMultipart, Default(http.MethodPost, MIMEMultipartPOSTForm))
+	assert.Equal(t, Form, Default(http.MethodPost, "application/unknown"))
+
+	// 带有结构化后缀的类型使用对应格式的 Binder
+	assert.Equal(t, JSON, Default(http.MethodPost, "application/vnd.api+json"))
+	assert.Equal(t, XML, Default(http.MethodPost, "application/atom+xml"))
+}
+
+func TestRegister(t *testing.T) {
+	Register("Application/CBOR", plainBinding{})
+	b, ok := Lookup("application/cbor")
+	assert.True(t, ok)
+	assert.Equal(t, plainBinding{}, b)
+	assert.Equal(t, plainBinding{}, Default(http.MethodPut, "application/cbor"))
+
+	assert.Panics(t, func() {
+		Register("application/cbor", nil)
+	})
+}
+
+func TestMsgPack(t *testing.T) {
+	type user struct {
+		Name  string `json:"name"`
+		Email string `json:"email,omitempty"`
+		Age   int    `json:"age" msgpack:"user_age"`
+	}
+
+	// 字段名来自 json 标签，msgpack 标签优先
+	body, err := msgpack.Marshal(map[string]interface{}{"name": "kobe", "email": "kobe@example.com", "user_age": 24})
+	assert.Nil(t, err)
+	var u user
+	assert.Nil(t, MsgPack.BindBody(body, &u))
+	assert.Equal(t, user{Name: "kobe", Email: "kobe@example.com", Age: 24}, u)
+}
+
+func TestURI(t *testing.T) {
+	type uriPost struct {
+		ID   uint64 `uri:"id" binding:"required"`
+		Slug string `uri:"slug" binding:"required,max=16"`
+		Page *int   `uri:"page"`
+	}
+	var post uriPost
+	err := URI.BindURI(map[string][]string{"id": {"42"}, "slug": {"hello-gee"}}, &post)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(42), post.ID)
+	assert.Equal(t, "hello-gee", post.Slug)
+	assert.Nil(t, post.Page)
+
+	assert.NotNil(t, URI.BindURI(map[string][]string{"id": {"abc"}, "slug": {"x"}}, &uriPost{}))
+	assert.IsType(t, ValidationErrors{}, URI.BindURI(map[string][]string{"id": {"1"}}, &uriPost{}))
+}
+
+func TestHeader(t *testing.T) {
+	type headers struct {
+		Tenant         string        `header:"x-tenant-id" binding:"required"`
+		Locale         string        `header:"Accept-Language"`
+		IdempotencyKey *string       `header:"Idempotency-Key"`
+		Version        int           `header:"X-Client-Version"`
+		Via            []string      `header:"Via"`
+		Since          time.Time     `header:"X-Since" time_format:"2006-01-02" time_utc:"true"`
+		Timeout        time.Duration `header:"X-Timeout"`
+		Ignored        string        `header:"-"`
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	req.Header.Set("Accept-Language", "zh-CN")
+	req.Header.Set("Idempotency-Key", "abc")
+	req.Header.Set("X-Client-Version", "3")
+	req.Header.Add("Via", "1.1 a")
+	req.Header.Add("Via", "1.1 b")
+	req.Header.Set("X-Since", "2021-03-04")
+	req.Header.Set("X-Timeout", "1m30s")
+	req.Header.Set("Ignored", "x")
+
+	var h headers
+	assert.Nil(t, Header.Bind(req, &h))
+	assert.Equal(t, "acme", h.Tenant)
+	assert.Equal(t, "zh-CN", h.Locale)
+	assert.Equal(t, "abc", *h.IdempotencyKey)
+	assert.Equal(t, 3, h.Version)
+	assert.Equal(t, []string{"1.1 a", "1.1 b"}, h.Via)
+	assert.Equal(t, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), h.Since)
+	assert.Equal(t, 90*time.Second, h.Timeout)
+	assert.Equal(t, "", h.Ignored)
+
+	req.Header.Set("X-Timeout", "soon")
+	assert.NotNil(t, Header.Bind(req, &headers{}))
+
+	req, _ = http.NewRequest(http.MethodGet, "/", nil)
+	assert.IsType(t, ValidationErrors{}, Header.Bind(req, &headers{}))
+}
+
+func TestMultipartFiles(t *testing.T) {
+	type upload struct {
+		Title       string                  `form:"title" binding:"required"`
+		Avatar      *multipart.FileHeader   `form:"avatar" binding:"required"`
+		Attachments []*multipart.FileHeader `form:"attachments" binding:"max=2"`
+		Missing     *multipart.FileHeader   `form:"missing"`
+	}
+
+	newRequest := func(files map[string][]string) *http.Request {
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		_ = mw.WriteField("title", "report")
+		for field, names := range files {
+			for _, name := range names {
+				fw, _ := mw.CreateFormFile(field, name)
+				_, _ = fw.Write([]byte("content of " + name))
+			}
+		}
+		_ = mw.Close()
+		req, _ := http.NewRequest(http.MethodPost, "/", body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		return req
+	}
+
+	var u upload
+	req := newRequest(map[string][]string{"avatar": {"me.png"}, "attachments": {"a.txt", "b.txt"}})
+	assert.Nil(t, FormMultipart.Bind(req, &u))
+	assert.Equal(t, "report", u.Title)
+	assert.Equal(t, "me.png", u.Avatar.Filename)
+	assert.Len(t, u.Attachments, 2)
+	assert.Equal(t, "b.txt", u.Attachments[1].Filename)
+	assert.Nil(t, u.Missing)
+
+	f, err := u.Attachments[0].Open()
+	assert.Nil(t, err)
+	content, _ := ioutil.ReadAll(f)
+	_ = f.Close()
+	assert.Equal(t, "content of a.txt", string(content))
+
+	// Form 解析 multipart 请求时同样会绑定文件
+	u = upload{}
+	req = newRequest(map[string][]string{"avatar": {"me.png"}})
+	assert.Nil(t, Form.Bind(req, &u))
+	assert.Equal(t, "me.png", u.Avatar.Filename)
+
+	req = newRequest(map[string][]string{"attachments": {"a.txt"}})
+	err = FormMultipart.Bind(req, &upload{})
+	assert.Equal(t, "Avatar", err.(ValidationErrors)[0].Field)
+
+	req = newRequest(map[string][]string{"avatar": {"me.png"}, "attachments": {"a", "b", "c"}})
+	assert.Equal(t, "max", FormMultipart.Bind(req, &upload{}).(ValidationErrors)[0].Rule)
+
+	// 嵌套结构体中的文件按 "user[avatar]"、"user.avatar" 查找，只有文件时同样可以绑定
+	type profile struct {
+		Name   string                  `form:"name"`
+		Avatar *multipart.FileHeader   `form:"avatar" binding:"required"`
+		Photos []*multipart.FileHeader `form:"photos"`
+	}
+	type account struct {
+		User  profile  `form:"user"`
+		Admin *profile `form:"admin"`
+	}
+	var a account
+	req = newRequest(map[string][]string{"user[avatar]": {"me.png"}, "user.photos[]": {"a.png", "b.png"}, "admin.avatar": {"root.png"}})
+	assert.Nil(t, FormMultipart.Bind(req, &a))
+	assert.Equal(t, "me.png", a.User.Avatar.Filename)
+	assert.Len(t, a.User.Photos, 2)
+	assert.Equal(t, "root.png", a.Admin.Avatar.Filename)
+
+	req = newRequest(map[string][]string{"admin[avatar]": {"root.png"}})
+	err = FormMultipart.Bind(req, &account{})
+	assert.Equal(t, "Avatar", err.(ValidationErrors)[0].Field)
+
+	// obj 为 nil 时不解析也不 panic
+	req = newRequest(map[string][]string{"avatar": {"me.png"}})
+	assert.NotPanics(t, func() {
+		assert.Nil(t, Form.Bind(req, nil))
+	})
+	req, _ = http.NewRequest(http.MethodGet, "/?title=report", nil)
+	assert.Nil(t, Form.Bind(req, nil))
+}
+
+func TestNestedForm(t *testing.T) {
+	type item struct {
+		ID    int    `form:"id" binding:"required"`
+		Title string `form:"title"`
+	}
+	type profile struct {
+		Name string `form:"name"`
+		Age  int    `form:"age"`
+	}
+	type order struct {
+		User     profile           `form:"user"`
+		Owner    *profile          `form:"owner"`
+		Items    []item            `form:"items"`
+		Refs     []*item           `form:"refs"`
+		Tags     []string          `form:"tags"`
+		Scores   []int             `form:"scores"`
+		Meta     map[string]string `form:"meta"`
+		Counts   map[string]int    `form:"counts"`
+		Groups   map[string]item   `form:"groups"`
+		Matrix   [][]string        `form:"matrix"`
+		Comment  string            `form:"comment"`
+		Settings map[string]string `form:"settings"`
+	}
+
+	query := "user[name]=knight&user.age=18&owner[name]=gee" +
+		"&items[0][id]=3&items[0][title]=book&items[1][id]=5" +
+		"&refs[0].id=7&tags[]=a&tags[]=b&scores[1]=20&scores[0]=10" +
+		"&meta.env=prod&meta[region]=cn&counts[x]=1&groups[vip][id]=9" +
+		"&matrix[0][0]=a&matrix[0][1]=b&matrix[1][0]=c" +
+		`&comment=hi&settings={"k":"v"}`
+	req, _ := http.NewRequest(http.MethodGet, "/?"+query, nil)
+
+	var o order
+	assert.Nil(t, Form.Bind(req, &o))
+	assert.Equal(t, profile{Name: "knight", Age: 18}, o.User)
+	assert.Equal(t, &profile{Name: "gee"}, o.Owner)
+	assert.Equal(t, []item{{ID: 3, Title: "book"}, {ID: 5}}, o.Items)
+	assert.Equal(t, []*item{{ID: 7}}, o.Refs)
+	assert.Equal(t, []string{"a", "b"}, o.Tags)
+	assert.Equal(t, []int{10, 20}, o.Scores)
+	assert.Equal(t, map[string]string{"env": "prod", "region": "cn"}, o.Meta)
+	assert.Equal(t, map[string]int{"x": 1}, o.Counts)
+	assert.Equal(t, map[string]item{"vip": {ID: 9}}, o.Groups)
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, o.Matrix)
+	assert.Equal(t, "hi", o.Comment)
+	// 没有嵌套 key 时依然使用 JSON 解析
+	assert.Equal(t, map[string]string{"k": "v"}, o.Settings)
+
+	// 嵌套结构体依然可以使用不带前缀的 key
+	var p struct {
+		Profile profile `form:"profile"`
+	}
+	req, _ = http.NewRequest(http.MethodGet, "/?name=knight&age=18", nil)
+	assert.Nil(t, Form.Bind(req, &p))
+	assert.Equal(t, profile{Name: "knight", Age: 18}, p.Profile)
+
+	// 切片中的结构体会被校验
+	req, _ = http.NewRequest(http.MethodGet, "/?items[0][id]=1&items[1][title]=x", nil)
+	err := Form.Bind(req, &order{})
+	assert.Equal(t, "order.Items[1].ID", err.(ValidationErrors)[0].Namespace)
+
+	req, _ = http.NewRequest(http.MethodGet, "/?items[0][id]=x", nil)
+	assert.NotNil(t, Form.Bind(req, &order{}))
+
+	// 切片元素保留下标对应的位置
+	req, _ = http.NewRequest(http.MethodGet, "/?scores[2]=30&scores[0]=10", nil)
+	o = order{}
+	assert.Nil(t, Form.Bind(req, &o))
+	assert.Equal(t, []int{10, 0, 30}, o.Scores)
+
+	// 下标超过数组长度、不是数字或过大时返回 BindError
+	var a struct {
+		Pair [2]int `form:"pair"`
+	}
+	req, _ = http.NewRequest(http.MethodGet, "/?pair[0]=1&pair[2]=3", nil)
+	err = Form.Bind(req, &a)
+	var bindErr *BindError
+	assert.True(t, errors.As(err, &bindErr))
+	assert.Equal(t, "Pair", bindErr.Field)
+	assert.Equal(t, "pair", bindErr.Key)
+	assert.Equal(t, "3", bindErr.Value)
+	assert.EqualError(t, bindErr.Err, "too many values for [2]int")
+
+	req, _ = http.NewRequest(http.MethodGet, "/?scores[0]=1&scores[x]=2", nil)
+	err = Form.Bind(req, &order{})
+	assert.True(t, errors.As(err, &bindErr))
+	assert.Equal(t, "scores", bindErr.Key)
+	assert.EqualError(t, bindErr.Err, `invalid index "x" for []int`)
+
+	req, _ = http.NewRequest(http.MethodGet, "/?scores[100000000]=1", nil)
+	assert.True(t, errors.As(Form.Bind(req, &order{}), &bindErr))
+}
+
+func TestSplitKey(t *testing.T) {
+	assert.Equal(t, []string{"items", "0", "id"}, splitKey("items[0][id]"))
+	assert.Equal(t, []string{"user", "name"}, splitKey("user.name"))
+	assert.Equal(t, []string{"a", "b", "c", "d"}, splitKey("a.b[c].d"))
+	assert.Equal(t, []string{"tags"}, splitKey("tags[]"))
+	assert.Equal(t, []string{"name"}, splitKey("name"))
+	assert.Equal(t, []string{"bad", "[x"}, splitKey("bad[x"))
+}
+
+type level int
+
+func (l *level) UnmarshalParam(param string) error {
+	switch param {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+type point struct {
+	X, Y int
+}
+
+func (p *point) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+func TestFormDefaultAndUnmarshaler(t *testing.T) {
+	type query struct {
+		Page     int           `form:"page" default:"1"`
+		Size     int           `form:"size" default:"10"`
+		Sort     string        `form:"sort" default:"id"`
+		Fields   []string      `form:"fields" default:"id,name"`
+		Timeout  time.Duration `form:"timeout" default:"5s"`
+		Level    level         `form:"level" default:"low"`
+		Levels   []level       `form:"levels"`
+		Origin   point         `form:"origin"`
+		Target   *point        `form:"target"`
+		IP       net.IP        `form:"ip"`
+		Optional *int          `form:"optional"`
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/?size=&page=3&levels=high&levels=low&origin=1,2&target=3,4&ip=10.0.0.1", nil)
+	var q query
+	assert.Nil(t, Form.Bind(req, &q))
+	assert.Equal(t, 3, q.Page)
+	assert.Equal(t, 10, q.Size)
+	assert.Equal(t, "id", q.Sort)
+	assert.Equal(t, []string{"id", "name"}, q.Fields)
+	assert.Equal(t, 5*time.Second, q.Timeout)
+	assert.Equal(t, level(1), q.Level)
+	assert.Equal(t, []level{2, 1}, q.Levels)
+	assert.Equal(t, point{1, 2}, q.Origin)
+	assert.Equal(t, &point{3, 4}, q.Target)
+	assert.Equal(t, "10.0.0.1", q.IP.String())
+	assert.Nil(t, q.Optional)
+
+	// 错误信息包含字段名和输入的值
+	req, _ = http.NewRequest(http.MethodGet, "/?page=abc", nil)
+	err := Form.Bind(req, &query{})
+	var bindErr *BindError
+	assert.True(t, errors.As(err, &bindErr))
+	assert.Equal(t, "Page", bindErr.Field)
+	assert.Equal(t, "page", bindErr.Key)
+	assert.Equal(t, "abc", bindErr.Value)
+	assert.Contains(t, err.Error(), `cannot bind "abc" to field 'Page' (key "page")`)
+
+	req, _ = http.NewRequest(http.MethodGet, "/?levels=low&levels=medium", nil)
+	err = Form.Bind(req, &query{})
+	assert.EqualError(t, err, `binding: cannot bind "medium" to field 'Levels' (key "levels"): unknown level`)
+
+	var unsupported struct {
+		Ch chan int `form:"ch"`
+	}
+	req, _ = http.NewRequest(http.MethodGet, "/?ch=1", nil)
+	assert.EqualError(t, Form.Bind(req, &unsupported), `binding: cannot bind "1" to field 'Ch' (key "ch"): unsupported type chan int`)
+
+	var array struct {
+		Pair [2]int `form:"pair"`
+	}
+	req, _ = http.NewRequest(http.MethodGet, "/?pair=1&pair=2&pair=3", nil)
+	assert.NotNil(t, Form.Bind(req, &array))
+}
@@ -0,0 +1,124 @@
+package binding
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+type csvBinding struct{}
+
+func (b csvBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return fmt.Errorf("invalid request")
+	}
+	return decodeCSV(req.Body, obj)
+}
+
+func (b csvBinding) BindBody(body []byte, obj interface{}) error {
+	return decodeCSV(bytes.NewReader(body), obj)
+}
+
+// decodeCSV 将 CSV 解析到结构体（或结构体指针）的切片中，第一行为表头。
+// 表头通过 csv 标签与字段对应，没有标签时与字段名（不区分大小写）对应，
+// 字段的类型转换与表单解析相同
+func decodeCSV(r io.Reader, obj interface{}) error {
+	value := reflect.ValueOf(obj)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Slice {
+		return errors.New("csv: obj must be a pointer to a slice of structs")
+	}
+	sliceValue := value.Elem()
+	elemType := sliceValue.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return errors.New("csv: obj must be a pointer to a slice of structs")
+	}
+
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		sliceValue.Set(reflect.MakeSlice(sliceValue.Type(), 0, 0))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(header) > 0 {
+		// 去掉 Excel 导出的 CSV 开头的 BOM
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	columns := csvColumns(elemType, header)
+
+	result := reflect.MakeSlice(sliceValue.Type(), 0, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		elem := reflect.New(elemType)
+		for i, val := range record {
+			if i >= len(columns) || columns[i] < 0 {
+				continue
+			}
+			field := elemType.Field(columns[i])
+			if err := setCSVValue(val, elem.Elem().Field(columns[i]), field); err != nil {
+				return fmt.Errorf("csv: line %d, column %q: %w", line, header[i], err)
+			}
+		}
+
+		if isPtr {
+			result = reflect.Append(result, elem)
+		} else {
+			result = reflect.Append(result, elem.Elem())
+		}
+	}
+
+	sliceValue.Set(result)
+	return validate(obj)
+}
+
+// csvColumns 返回每一列对应的字段下标，没有对应字段的列为 -1
+func csvColumns(t reflect.Type, header []string) []int {
+	columns := make([]int, len(header))
+	for i, name := range header {
+		columns[i] = -1
+		name = strings.TrimSpace(name)
+		for j := 0; j < t.NumField(); j++ {
+			f := t.Field(j)
+			tag := f.Tag.Get("csv")
+			if f.PkgPath != "" || tag == "-" {
+				continue
+			}
+			if tag == name || (tag == "" && strings.EqualFold(f.Name, name)) {
+				columns[i] = j
+				break
+			}
+		}
+	}
+	return columns
+}
+
+func setCSVValue(val string, value reflect.Value, field reflect.StructField) error {
+	if value.Kind() == reflect.Ptr {
+		if val == "" {
+			return nil
+		}
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	return setProperValue(val, value, field)
+}
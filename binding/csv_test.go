@@ -0,0 +1,45 @@
+package binding
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type csvRow struct {
+	ID       int       `csv:"id"`
+	Name     string    `csv:"name"`
+	Score    *float64  `csv:"score"`
+	Birthday time.Time `csv:"birthday" time_format:"2006-01-02" time_utc:"true"`
+	Male     bool
+}
+
+func TestCSVBinding(t *testing.T) {
+	body := "\ufeffid,name,score,birthday,male,unknown\n1,\"knight, jr\",99.5,2000-01-02,true,x\n2,kobe,,,false,y\n"
+	req, _ := http.NewRequest(http.MethodPost, "/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", MIMECSV)
+
+	var rows []csvRow
+	assert.Nil(t, Default(req.Method, MIMECSV).Bind(req, &rows))
+	assert.Len(t, rows, 2)
+	assert.Equal(t, 1, rows[0].ID)
+	assert.Equal(t, "knight, jr", rows[0].Name)
+	assert.Equal(t, 99.5, *rows[0].Score)
+	assert.Equal(t, time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC), rows[0].Birthday)
+	assert.True(t, rows[0].Male)
+	assert.Nil(t, rows[1].Score)
+	assert.True(t, rows[1].Birthday.IsZero())
+
+	var ptrs []*csvRow
+	assert.Nil(t, CSV.BindBody([]byte("id,name\n3,curry\n"), &ptrs))
+	assert.Equal(t, "curry", ptrs[0].Name)
+
+	err := CSV.BindBody([]byte("id\nabc\n"), &rows)
+	assert.EqualError(t, err, `csv: line 2, column "id": strconv.ParseInt: parsing "abc": invalid syntax`)
+
+	var row csvRow
+	assert.NotNil(t, CSV.BindBody([]byte("id\n1\n"), &row))
+}
@@ -1,11 +1,15 @@
 package binding
 
 import (
+	"encoding"
 	"encoding/json"
-	"errors"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,6 +25,9 @@ func (b formBinding) Bind(req *http.Request, obj interface{}) error {
 			return err
 		}
 	}
+	if req.MultipartForm != nil {
+		return mapFormByTag(obj, newMultipartValues(req.Form, req.MultipartForm.File), "form")
+	}
 	if err := mapForm(obj, req.Form); err != nil {
 		return err
 	}
@@ -43,20 +50,118 @@ func (b multipartBinding) Bind(req *http.Request, obj interface{}) error {
 		return err
 	}
 
-	return mapForm(obj, req.PostForm)
+	return mapFormByTag(obj, newMultipartValues(req.PostForm, req.MultipartForm.File), "form")
+}
+
+// formSource 解析的数据来源，根据标签中的 key 查找对应的值
+type formSource interface {
+	Get(key string) ([]string, bool)
+}
+
+// formValues 按 key 原样查找，用于表单和路由参数
+type formValues map[string][]string
+
+func (f formValues) Get(key string) ([]string, bool) {
+	v, ok := f[key]
+	return v, ok
+}
+
+// fileSource 可以获取上传文件的数据来源
+type fileSource interface {
+	GetFiles(key string) ([]*multipart.FileHeader, bool)
+}
+
+// multipartValues multipart 表单，除了普通字段外，
+// 类型为 *multipart.FileHeader 和 []*multipart.FileHeader 的字段会从上传的文件中获取；
+// 文件的 key 和普通字段一样按嵌套写法解析，prefix 是嵌套结构体所在的路径
+type multipartValues struct {
+	*formNode
+	files  map[string][]*multipart.FileHeader
+	prefix []string
+}
+
+func newMultipartValues(form map[string][]string, files map[string][]*multipart.FileHeader) multipartValues {
+	// 排序保证 "docs" 和 "docs[]" 同时存在时文件的顺序是确定的
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	m := multipartValues{formNode: newFormNode(form), files: make(map[string][]*multipart.FileHeader, len(files))}
+	for _, key := range keys {
+		path := pathKey(splitKey(key))
+		m.files[path] = append(m.files[path], files[key]...)
+	}
+	return m
+}
+
+func (m multipartValues) GetFiles(key string) ([]*multipart.FileHeader, bool) {
+	fhs, ok := m.files[pathKey(m.path(key))]
+	return fhs, ok
+}
+
+// sub 返回 key 下嵌套结构体的数据来源，普通字段和上传的文件都从 key 下查找
+func (m multipartValues) sub(key string) (multipartValues, bool) {
+	path := m.path(key)
+	node, ok := m.formNode.Sub(key)
+	if !ok {
+		if !m.hasFiles(path) {
+			return m, false
+		}
+		node = &formNode{}
+	}
+	return multipartValues{formNode: node, files: m.files, prefix: path}, true
+}
+
+func (m multipartValues) path(key string) []string {
+	return append(append([]string(nil), m.prefix...), splitKey(key)...)
+}
+
+// hasFiles 判断 path 下是否有上传的文件
+func (m multipartValues) hasFiles(path []string) bool {
+	prefix := pathKey(path) + "\x00"
+	for key := range m.files {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathKey 将拆分后的 key 拼接成 files 中的 key，"user[avatar]" 和 "user.avatar" 对应同一个 key
+func pathKey(parts []string) string {
+	return strings.Join(parts, "\x00")
 }
 
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
 func mapForm(obj interface{}, form map[string][]string) error {
-	_, err := mapping(reflect.ValueOf(obj), reflect.StructField{}, form, "form")
-	return err
+	return mapFormByTag(obj, newFormNode(form), "form")
 }
 
-func mapping(value reflect.Value, field reflect.StructField, form map[string][]string, tag string) (bool, error) {
-	// 当 tag 的值为 "-" 时，表示该字段不用解析
-	if field.Tag.Get(tag) == "-" {
+// mapFormByTag 根据 tag 标签将 form 映射到 obj 中，并进行校验
+func mapFormByTag(obj interface{}, form formSource, tag string) error {
+	if _, err := mapping(reflect.ValueOf(obj), reflect.StructField{}, form, tag); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func mapping(value reflect.Value, field reflect.StructField, form formSource, tag string) (bool, error) {
+	// 当 tag 的值为 "-" 时，表示该字段不用解析；obj 为 nil 时 value 无效，同样不解析
+	if field.Tag.Get(tag) == "-" || !value.IsValid() {
 		return false, nil
 	}
 
+	// 上传的文件只能从 multipart 表单中获取，不能当作普通的结构体解析
+	if t := value.Type(); t == fileHeaderType || t == fileHeaderSliceType {
+		return trySetFile(value, field, form, tag)
+	}
+
 	valueKind := value.Kind()
 
 	// value 是指针，判断指针是否为 nil； 当为 nil 是，要为其分配内存，
@@ -89,9 +194,22 @@ func mapping(value reflect.Value, field reflect.StructField, form map[string][]s
 		}
 	}
 
-	// 当 value 是结构体时，遍历其所以可导出字段，递归调用该函数
+	// 当 value 是结构体时，遍历其所以可导出字段，递归调用该函数；
+	// 存在 "user[name]"、"user.name" 这样的嵌套 key 时，字段从 user 下的子节点中查找
 	if valueKind == reflect.Struct {
 		valueType := value.Type()
+		if field.Name != "" && !field.Anonymous {
+			switch nested := form.(type) {
+			case multipartValues:
+				if sub, ok := nested.sub(tagKey(field, tag)); ok {
+					form = sub
+				}
+			case nestedSource:
+				if sub, ok := nested.Sub(tagKey(field, tag)); ok {
+					form = sub
+				}
+			}
+		}
 
 		isSet := false
 		for i := 0; i < valueType.NumField(); i++ {
@@ -111,11 +229,34 @@ func mapping(value reflect.Value, field reflect.StructField, form map[string][]s
 	return false, nil
 }
 
-func trySetValue(value reflect.Value, field reflect.StructField, form map[string][]string, tag string) (bool, error) {
-	tagValue := field.Tag.Get(tag)
-	if tagValue == "" {
-		tagValue = field.Name
+// tagKey 返回字段在 tag 标签中的名称，没有标签时使用字段名
+func tagKey(field reflect.StructField, tag string) string {
+	if tagValue := field.Tag.Get(tag); tagValue != "" {
+		return tagValue
+	}
+	return field.Name
+}
+
+func trySetFile(value reflect.Value, field reflect.StructField, form formSource, tag string) (bool, error) {
+	files, ok := form.(fileSource)
+	if !ok {
+		return false, nil
 	}
+
+	fhs, ok := files.GetFiles(tagKey(field, tag))
+	if !ok || len(fhs) == 0 {
+		return false, nil
+	}
+	if value.Type() == fileHeaderType {
+		value.Set(reflect.ValueOf(fhs[0]))
+	} else {
+		value.Set(reflect.ValueOf(fhs))
+	}
+	return true, nil
+}
+
+func trySetValue(value reflect.Value, field reflect.StructField, form formSource, tag string) (bool, error) {
+	tagValue := tagKey(field, tag)
 	if tagValue == "" {
 		return false, nil
 	}
@@ -123,31 +264,121 @@ func trySetValue(value reflect.Value, field reflect.StructField, form map[string
 	return setValue(value, tagValue, form, field, tag)
 }
 
-func setValue(value reflect.Value, tagValue string, form map[string][]string, field reflect.StructField, tag string) (bool, error) {
-	v, ok := form[tagValue]
+func setValue(value reflect.Value, tagValue string, form formSource, field reflect.StructField, tag string) (bool, error) {
+	v, ok := form.Get(tagValue)
 	if !ok {
-		return false, nil
+		// "items[0][id]"、"meta[key]" 这样的嵌套 key
+		if nested, isNested := form.(nestedSource); isNested {
+			if sub, ok := nested.Sub(tagValue); ok {
+				isSet, err := setNested(value, sub, field, tag)
+				if err != nil {
+					return false, bindError(err, field, tagValue)
+				}
+				return isSet, nil
+			}
+		}
 	}
 
-	switch value.Kind() {
-	case reflect.Slice:
-		err := setSlice(v, value, field)
-		if err != nil {
-			return false, err
+	// key 不存在或者值为空时使用 default 标签中的默认值，切片和数组的默认值以逗号分隔，如 `default:"a,b"`
+	if defaultValue, hasDefault := field.Tag.Lookup("default"); hasDefault && (!ok || len(v) == 1 && v[0] == "") {
+		v, ok = []string{defaultValue}, true
+		if isMultiValue(value) {
+			v = strings.Split(defaultValue, ",")
 		}
-		return true, nil
-	case reflect.Array:
-		err := setArray(v, value, field)
-		if err != nil {
-			return false, err
-		}
-		return true, nil
+	}
+	if !ok {
+		return false, nil
+	}
+
+	var err error
+	switch {
+	case isMultiValue(value) && value.Kind() == reflect.Slice:
+		err = setSlice(v, value, field)
+	case isMultiValue(value):
+		err = setArray(v, value, field)
 	default:
-		return true, setProperValue(v[0], value, field)
+		if err = setProperValue(v[0], value, field); err != nil {
+			err = &BindError{Value: v[0], Err: err}
+		}
 	}
+	if err != nil {
+		return false, bindError(err, field, tagValue)
+	}
+	return true, nil
+}
+
+// bindError 将 err 转换成 BindError 并补充字段名和 key，嵌套结构体中的字段已经设置过时保持不变
+func bindError(err error, field reflect.StructField, key string) *BindError {
+	bindErr, isBindErr := err.(*BindError)
+	if !isBindErr {
+		bindErr = &BindError{Err: err}
+	}
+	if bindErr.Key == "" {
+		bindErr.Field, bindErr.Key = field.Name, key
+	}
+	return bindErr
+}
+
+// isMultiValue 判断 value 是否使用多个值，net.IP 这样实现了 Unmarshaler 的切片只使用一个值
+func isMultiValue(value reflect.Value) bool {
+	kind := value.Kind()
+	return (kind == reflect.Slice || kind == reflect.Array) && !isUnmarshaler(value.Type())
+}
+
+// BindError 字段解析失败的错误，包含字段名、请求中的 key 和无法转换的值
+type BindError struct {
+	Field string
+	Key   string
+	Value string
+	Err   error
+}
+
+func (e *BindError) Error() string {
+	return fmt.Sprintf("binding: cannot bind %q to field '%s' (key %q): %v", e.Value, e.Field, e.Key, e.Err)
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// BindUnmarshaler 自定义表单、路由参数、请求头中单个值的解析方式，优先于 encoding.TextUnmarshaler
+type BindUnmarshaler interface {
+	UnmarshalParam(param string) error
+}
+
+var (
+	bindUnmarshalerType = reflect.TypeOf((*BindUnmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isUnmarshaler 判断 t 的指针是否实现了 BindUnmarshaler 或 encoding.TextUnmarshaler，
+// 实现了的结构体作为一个整体解析，不再映射其中的字段
+func isUnmarshaler(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return pt.Implements(bindUnmarshalerType) || pt.Implements(textUnmarshalerType)
+}
+
+// trySetUnmarshaler 使用 BindUnmarshaler 或 encoding.TextUnmarshaler 解析，time.Time 使用 time_format 等标签解析
+func trySetUnmarshaler(val string, value reflect.Value) (bool, error) {
+	if !value.CanAddr() {
+		return false, nil
+	}
+	switch u := value.Addr().Interface().(type) {
+	case BindUnmarshaler:
+		return true, u.UnmarshalParam(val)
+	case *time.Time:
+		return false, nil
+	case encoding.TextUnmarshaler:
+		return true, u.UnmarshalText([]byte(val))
+	}
+	return false, nil
 }
 
 func setProperValue(val string, value reflect.Value, field reflect.StructField) error {
+	if ok, err := trySetUnmarshaler(val, value); ok {
+		return err
+	}
+
 	switch value.Kind() {
 	case reflect.Int:
 		return setInt(val, 0, value)
@@ -192,16 +423,24 @@ func setProperValue(val string, value reflect.Value, field reflect.StructField)
 	case reflect.Map:
 		// FIXME: 字符串和字节数组的转化可能存在问题
 		return json.Unmarshal([]byte(val), value.Addr().Interface())
+	case reflect.Ptr:
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		return setProperValue(val, value.Elem(), field)
 	default:
-		return errors.New("unknown type")
+		return fmt.Errorf("unsupported type %s", value.Type())
 	}
 }
 
 func setArray(vals []string, value reflect.Value, field reflect.StructField) error {
 	for i, val := range vals {
+		if i >= value.Len() {
+			return &BindError{Value: val, Err: fmt.Errorf("too many values for %s", value.Type())}
+		}
 		err := setProperValue(val, value.Index(i), field)
 		if err != nil {
-			return err
+			return &BindError{Value: val, Err: err}
 		}
 	}
 	return nil
@@ -0,0 +1,224 @@
+package binding
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formNode 将表单的 key 按 HTML 表单的嵌套写法解析成树：
+// "user[name]" 和 "user.name" 都对应 user 节点下的 name 节点，
+// "items[0][id]" 对应 items 节点下 0 节点中的 id 节点，"tags[]" 与 "tags" 相同
+type formNode struct {
+	values   []string
+	children map[string]*formNode
+}
+
+// nestedSource 支持嵌套 key 的数据来源
+type nestedSource interface {
+	Sub(key string) (*formNode, bool)
+}
+
+func newFormNode(form map[string][]string) *formNode {
+	// 排序保证 "tags" 和 "tags[]" 同时存在时值的顺序是确定的
+	keys := make([]string, 0, len(form))
+	for key := range form {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	root := &formNode{}
+	for _, key := range keys {
+		node := root
+		for _, part := range splitKey(key) {
+			node = node.child(part)
+		}
+		node.values = append(node.values, form[key]...)
+	}
+	return root
+}
+
+func (n *formNode) child(name string) *formNode {
+	if n.children == nil {
+		n.children = make(map[string]*formNode)
+	}
+	c, ok := n.children[name]
+	if !ok {
+		c = &formNode{}
+		n.children[name] = c
+	}
+	return c
+}
+
+func (n *formNode) lookup(key string) (*formNode, bool) {
+	parts := splitKey(key)
+	if len(parts) == 0 {
+		return nil, false
+	}
+	node := n
+	for _, part := range parts {
+		c, ok := node.children[part]
+		if !ok {
+			return nil, false
+		}
+		node = c
+	}
+	return node, true
+}
+
+func (n *formNode) Get(key string) ([]string, bool) {
+	node, ok := n.lookup(key)
+	if !ok || len(node.values) == 0 {
+		return nil, false
+	}
+	return node.values, true
+}
+
+// Sub 返回 key 下的子节点，如 key 为 "user" 时，子节点中的 "name" 对应 "user[name]"
+func (n *formNode) Sub(key string) (*formNode, bool) {
+	node, ok := n.lookup(key)
+	if !ok || len(node.children) == 0 {
+		return nil, false
+	}
+	return node, true
+}
+
+// splitKey 将 "items[0][id]"、"meta.key"、"tags[]" 拆分成 ["items" "0" "id"]、["meta" "key"]、["tags"]，
+// 空的部分（如 "[]"）会被忽略，缺少 "]" 时剩余部分作为一个整体
+func splitKey(key string) []string {
+	var parts []string
+	for key != "" {
+		var part string
+		if key[0] == '[' {
+			end := strings.IndexByte(key, ']')
+			if end < 0 {
+				parts = append(parts, key)
+				break
+			}
+			part, key = key[1:end], strings.TrimPrefix(key[end+1:], ".")
+		} else if i := strings.IndexAny(key, ".["); i >= 0 {
+			part = key[:i]
+			if key[i] == '.' {
+				key = key[i+1:]
+			} else {
+				key = key[i:]
+			}
+		} else {
+			part, key = key, ""
+		}
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// maxNestedIndex 切片下标的上限，避免 "items[100000000]" 这样的 key 分配过大的切片
+const maxNestedIndex = 1 << 10
+
+// setNested 将 node 的子节点映射到切片、数组或 map 中：切片和数组的子节点名为下标，
+// 元素保留下标对应的位置，缺少的下标为零值，如 "scores[0]=1&scores[2]=3" 对应 [1 0 3]；
+// 下标不是数字、超过数组长度或 maxNestedIndex 时返回 BindError。map 的子节点名为 map 的 key
+func setNested(value reflect.Value, node *formNode, field reflect.StructField, tag string) (bool, error) {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		indexes := make([]int, 0, len(node.children))
+		for name, child := range node.children {
+			i, err := strconv.Atoi(name)
+			if err != nil || i < 0 {
+				return false, &BindError{Value: nodeValue(child), Err: fmt.Errorf("invalid index %q for %s", name, value.Type())}
+			}
+			if i >= maxNestedIndex || value.Kind() == reflect.Array && i >= value.Len() {
+				return false, &BindError{Value: nodeValue(child), Err: fmt.Errorf("too many values for %s", value.Type())}
+			}
+			indexes = append(indexes, i)
+		}
+		if len(indexes) == 0 {
+			return false, nil
+		}
+		sort.Ints(indexes)
+
+		if value.Kind() == reflect.Slice {
+			n := indexes[len(indexes)-1] + 1
+			value.Set(reflect.MakeSlice(value.Type(), n, n))
+		}
+		for _, index := range indexes {
+			if err := setNestedElem(value.Index(index), node.children[strconv.Itoa(index)], field, tag); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	case reflect.Map:
+		if value.IsNil() {
+			value.Set(reflect.MakeMap(value.Type()))
+		}
+		names := make([]string, 0, len(node.children))
+		for name := range node.children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			key := reflect.New(value.Type().Key()).Elem()
+			if err := setProperValue(name, key, field); err != nil {
+				return false, err
+			}
+			elem := reflect.New(value.Type().Elem()).Elem()
+			if err := setNestedElem(elem, node.children[name], field, tag); err != nil {
+				return false, err
+			}
+			value.SetMapIndex(key, elem)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// setNestedElem 结构体元素按子节点的 key 映射字段，其他元素使用节点的值
+func setNestedElem(elem reflect.Value, node *formNode, field reflect.StructField, tag string) error {
+	t := elem.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isNestedStruct(t) {
+		_, err := mapping(elem, reflect.StructField{}, node, tag)
+		return err
+	}
+
+	for elem.Kind() == reflect.Ptr {
+		if elem.IsNil() {
+			elem.Set(reflect.New(elem.Type().Elem()))
+		}
+		elem = elem.Elem()
+	}
+	// 多层嵌套，如 "matrix[0][1]=x"
+	if len(node.values) == 0 && len(node.children) > 0 {
+		_, err := setNested(elem, node, field, tag)
+		return err
+	}
+	if isMultiValue(elem) {
+		if elem.Kind() == reflect.Slice {
+			return setSlice(node.values, elem, field)
+		}
+		return setArray(node.values, elem, field)
+	}
+	if len(node.values) == 0 {
+		return nil
+	}
+	return setProperValue(node.values[0], elem, field)
+}
+
+// nodeValue 返回节点的第一个值，用于错误信息
+func nodeValue(node *formNode) string {
+	if len(node.values) == 0 {
+		return ""
+	}
+	return node.values[0]
+}
+
+// isNestedStruct 判断 t 是否需要按字段映射，time.Time 等实现了 Unmarshaler 的结构体作为一个整体解析
+func isNestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !isUnmarshaler(t)
+}
@@ -0,0 +1,25 @@
+package binding
+
+import (
+	"net/http"
+	"net/textproto"
+)
+
+var Header = headerBinding{}
+
+type headerBinding struct{}
+
+// Bind 通过 header 标签将请求头映射到结构体字段中，标签中的名称不区分大小写，
+// 如 `header:"x-tenant-id"` 对应请求头 X-Tenant-Id。重复的请求头可以解析到切片中，
+// time.Time、time.Duration 等类型的转换与表单解析相同
+func (headerBinding) Bind(req *http.Request, obj interface{}) error {
+	return mapFormByTag(obj, headerValues(req.Header), "header")
+}
+
+// headerValues 查找前将 key 转换成规范的请求头名称
+type headerValues http.Header
+
+func (h headerValues) Get(key string) ([]string, bool) {
+	v, ok := h[textproto.CanonicalMIMEHeaderKey(key)]
+	return v, ok
+}
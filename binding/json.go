@@ -1,24 +1,30 @@
 package binding
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 )
 
-type jsonBinding struct {}
+type jsonBinding struct{}
 
 func (b jsonBinding) Bind(req *http.Request, obj interface{}) error {
 	if req == nil || req.Body == nil {
 		return fmt.Errorf("invalid request")
 	}
-	return decodeJson(req, obj)
+	return decodeJson(req.Body, obj)
 }
 
-func decodeJson(req *http.Request, obj interface{}) error {
-	decoder := json.NewDecoder(req.Body)
+func (b jsonBinding) BindBody(body []byte, obj interface{}) error {
+	return decodeJson(bytes.NewReader(body), obj)
+}
+
+func decodeJson(r io.Reader, obj interface{}) error {
+	decoder := json.NewDecoder(r)
 	if err := decoder.Decode(obj); err != nil {
 		return err
 	}
-	return nil
+	return validate(obj)
 }
@@ -0,0 +1,254 @@
+package binding
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale 没有匹配的语言时使用的语言
+const DefaultLocale = "en"
+
+// messages 校验错误信息的模板，按语言和规则索引。规则名后可以加上 ".string"、".number"、".items"
+// 后缀，分别用于字符串、数值以及切片和 map，如 min 对字符串比较的是长度，对数值比较的是大小。
+// 模板中的 {field}、{param}、{rule} 会被替换成字段名、规则参数和规则名
+var (
+	messagesMu sync.RWMutex
+	messages   = map[string]map[string]string{
+		"en": {
+			"default":    "{field} failed on the {rule} rule",
+			"required":   "{field} is required",
+			"len.string": "{field} must be {param} characters long",
+			"len.number": "{field} must be equal to {param}",
+			"len.items":  "{field} must contain {param} items",
+			"min.string": "{field} must be at least {param} characters long",
+			"min.number": "{field} must be at least {param}",
+			"min.items":  "{field} must contain at least {param} items",
+			"max.string": "{field} must be at most {param} characters long",
+			"max.number": "{field} must be at most {param}",
+			"max.items":  "{field} must contain at most {param} items",
+			"eq":         "{field} must be equal to {param}",
+			"ne":         "{field} must not be equal to {param}",
+			"gt.string":  "{field} must be longer than {param} characters",
+			"gt.number":  "{field} must be greater than {param}",
+			"gt.items":   "{field} must contain more than {param} items",
+			"gte.string": "{field} must be at least {param} characters long",
+			"gte.number": "{field} must be at least {param}",
+			"gte.items":  "{field} must contain at least {param} items",
+			"lt.string":  "{field} must be shorter than {param} characters",
+			"lt.number":  "{field} must be less than {param}",
+			"lt.items":   "{field} must contain fewer than {param} items",
+			"lte.string": "{field} must be at most {param} characters long",
+			"lte.number": "{field} must be at most {param}",
+			"lte.items":  "{field} must contain at most {param} items",
+			"oneof":      "{field} must be one of [{param}]",
+			"email":      "{field} must be a valid email address",
+			"url":        "{field} must be a valid URL",
+			"alpha":      "{field} can only contain letters",
+			"alphanum":   "{field} can only contain letters and numbers",
+			"numeric":    "{field} must be a valid number",
+			// 类型转换失败，见 TypeError
+			"type":        "{field} has an invalid value",
+			"type.string": "{field} must be a string",
+			"type.number": "{field} must be a number",
+			"type.bool":   "{field} must be a boolean",
+			"type.items":  "{field} must be an array or object",
+		},
+		"zh": {
+			"default":    "{field}未通过{rule}校验",
+			"required":   "{field}为必填字段",
+			"len.string": "{field}长度必须是{param}个字符",
+			"len.number": "{field}必须等于{param}",
+			"len.items":  "{field}必须包含{param}项",
+			"min.string": "{field}长度必须至少为{param}个字符",
+			"min.number": "{field}必须大于或等于{param}",
+			"min.items":  "{field}必须至少包含{param}项",
+			"max.string": "{field}长度不能超过{param}个字符",
+			"max.number": "{field}必须小于或等于{param}",
+			"max.items":  "{field}最多只能包含{param}项",
+			"eq":         "{field}必须等于{param}",
+			"ne":         "{field}不能等于{param}",
+			"gt.string":  "{field}长度必须大于{param}个字符",
+			"gt.number":  "{field}必须大于{param}",
+			"gt.items":   "{field}必须包含超过{param}项",
+			"gte.string": "{field}长度必须至少为{param}个字符",
+			"gte.number": "{field}必须大于或等于{param}",
+			"gte.items":  "{field}必须至少包含{param}项",
+			"lt.string":  "{field}长度必须小于{param}个字符",
+			"lt.number":  "{field}必须小于{param}",
+			"lt.items":   "{field}必须少于{param}项",
+			"lte.string": "{field}长度不能超过{param}个字符",
+			"lte.number": "{field}必须小于或等于{param}",
+			"lte.items":  "{field}最多只能包含{param}项",
+			"oneof":      "{field}必须是[{param}]中的一个",
+			"email":      "{field}必须是一个有效的邮箱",
+			"url":        "{field}必须是一个有效的URL",
+			"alpha":      "{field}只能包含字母",
+			"alphanum":   "{field}只能包含字母和数字",
+			"numeric":    "{field}必须是一个有效的数值",
+			// 类型转换失败
+			"type":        "{field}的值无效",
+			"type.string": "{field}必须是字符串",
+			"type.number": "{field}必须是数值",
+			"type.bool":   "{field}必须是布尔值",
+			"type.items":  "{field}必须是数组或对象",
+		},
+	}
+)
+
+// RegisterMessages 添加或覆盖 locale 语言的错误信息，可用于新增语言或为自定义规则添加错误信息，
+// 如：RegisterMessages("en", map[string]string{"prefix": "{field} must start with {param}"})
+func RegisterMessages(locale string, msgs map[string]string) {
+	locale = normalizeLocale(locale)
+
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+	catalog, ok := messages[locale]
+	if !ok {
+		catalog = make(map[string]string, len(msgs))
+		messages[locale] = catalog
+	}
+	for key, msg := range msgs {
+		catalog[key] = msg
+	}
+}
+
+// MatchLocale 返回与 locale（如 "zh-CN"）匹配的已注册语言，没有完全匹配时尝试主语言（如 "zh"）
+func MatchLocale(locale string) (string, bool) {
+	locale = normalizeLocale(locale)
+
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+	if _, ok := messages[locale]; ok {
+		return locale, true
+	}
+	if i := strings.IndexByte(locale, '-'); i > 0 {
+		if _, ok := messages[locale[:i]]; ok {
+			return locale[:i], true
+		}
+	}
+	return "", false
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(locale), "_", "-", -1))
+}
+
+// Translate 返回 locale 语言的错误信息，字段名使用 Path，语言或规则没有对应的信息时使用英文
+func (e *FieldError) Translate(locale string) string {
+	if matched, ok := MatchLocale(locale); ok {
+		locale = matched
+	} else {
+		locale = DefaultLocale
+	}
+
+	param := e.Param
+	if e.Rule == "oneof" {
+		param = strings.Join(strings.Fields(param), ", ")
+	}
+	replacer := strings.NewReplacer("{field}", e.Path, "{param}", param, "{rule}", e.Rule)
+	return replacer.Replace(e.message(locale))
+}
+
+func (e *FieldError) message(locale string) string {
+	ruleKeys := []string{e.Rule}
+	if e.Kind != reflect.Invalid {
+		ruleKeys = []string{e.Rule + "." + kindClass(e.Kind), e.Rule}
+	}
+
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+	// 优先使用规则对应的信息（即使是英文），都没有时才使用通用的 default
+	for _, keys := range [][]string{ruleKeys, {"default"}} {
+		for _, catalog := range []map[string]string{messages[locale], messages[DefaultLocale]} {
+			for _, key := range keys {
+				if msg, ok := catalog[key]; ok {
+					return msg
+				}
+			}
+		}
+	}
+	return "{field} failed on the {rule} rule"
+}
+
+// kindClass 将类型分为 string、bool、number、items 四类，用于选择错误信息
+func kindClass(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		return "items"
+	}
+	return "number"
+}
+
+// Translate 返回每个字段（以 Path 为键）在 locale 语言下的错误信息
+func (errs ValidationErrors) Translate(locale string) map[string]string {
+	msgs := make(map[string]string, len(errs))
+	for _, err := range errs {
+		msgs[err.Path] = err.Translate(locale)
+	}
+	return msgs
+}
+
+// TypeError 将 Bind 返回的类型转换错误（*BindError、*json.UnmarshalTypeError、*strconv.NumError）
+// 转换成规则为 "type" 的 FieldError，这样可以和校验错误一样通过 Translate 获取错误信息。
+// err 不是类型转换错误时返回 nil
+func TypeError(err error) *FieldError {
+	var bindErr *BindError
+	if errors.As(err, &bindErr) {
+		return &FieldError{
+			Namespace: bindErr.Field,
+			Field:     bindErr.Field,
+			Path:      bindErr.Key,
+			Rule:      "type",
+			Value:     bindErr.Value,
+			Kind:      numErrorKind(bindErr.Err),
+		}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		t := typeErr.Type
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		return &FieldError{
+			Namespace: typeErr.Struct + "." + typeErr.Field,
+			Field:     typeErr.Field,
+			Path:      typeErr.Field,
+			Rule:      "type",
+			Value:     typeErr.Value,
+			Kind:      t.Kind(),
+		}
+	}
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return &FieldError{
+			Namespace: numErr.Num,
+			Path:      numErr.Num,
+			Rule:      "type",
+			Value:     numErr.Num,
+			Kind:      numErrorKind(numErr),
+		}
+	}
+	return nil
+}
+
+// numErrorKind 根据解析函数判断期望的类型，无法判断时返回 reflect.Invalid，使用通用的错误信息
+func numErrorKind(err error) reflect.Kind {
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) {
+		return reflect.Invalid
+	}
+	if numErr.Func == "ParseBool" {
+		return reflect.Bool
+	}
+	return reflect.Float64
+}
@@ -0,0 +1,34 @@
+package binding
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type msgpackBinding struct{}
+
+func (b msgpackBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return fmt.Errorf("invalid request")
+	}
+	return decodeMsgPack(req.Body, obj)
+}
+
+func (b msgpackBinding) BindBody(body []byte, obj interface{}) error {
+	return decodeMsgPack(bytes.NewReader(body), obj)
+}
+
+// decodeMsgPack 优先使用 msgpack 标签，没有 msgpack 标签时使用 json 标签
+func decodeMsgPack(r io.Reader, obj interface{}) error {
+	decoder := msgpack.NewDecoder(r)
+	// 与 rendering.MsgPack 保持一致，结构体只需要 json 标签就可以同时用于 JSON 和 MsgPack
+	decoder.SetCustomStructTag("json")
+	if err := decoder.Decode(obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
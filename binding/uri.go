@@ -0,0 +1,16 @@
+package binding
+
+// URIBinder 从路由参数中解析数据，与 Binder 不同，它不读取 *http.Request
+type URIBinder interface {
+	BindURI(map[string][]string, interface{}) error
+}
+
+var URI = uriBinding{}
+
+type uriBinding struct{}
+
+// BindURI 通过 uri 标签将路由参数映射到结构体字段中，类型转换与表单解析相同，
+// 如路由 "/user/:id/:slug" 对应的结构体字段为 `uri:"id"`、`uri:"slug"`
+func (uriBinding) BindURI(params map[string][]string, obj interface{}) error {
+	return mapFormByTag(obj, formValues(params), "uri")
+}
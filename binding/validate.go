@@ -0,0 +1,501 @@
+package binding
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Validator 结构体校验器，所有 Binder 解析完成后都会调用 DefaultValidator 校验结果，
+// 可以替换成其他实现（如 go-playground/validator），设置为 nil 时不做校验
+type Validator interface {
+	ValidateStruct(obj interface{}) error
+}
+
+// RuleFunc 校验规则，value 为字段的值（指针已经解引用），param 为规则的参数，
+// 如 "min=3" 中的 "3"，校验通过时返回 true
+type RuleFunc func(value reflect.Value, param string) bool
+
+// DefaultValidator 默认的校验器，根据字段的 binding 标签进行校验，
+// 如 `binding:"required,min=3,max=64"`，规则之间以逗号分隔
+var DefaultValidator Validator = NewValidator()
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	// Namespace 字段路径，如 "User.Addresses[0].City"
+	Namespace string
+	// Field 结构体字段名
+	Field string
+	// Path 使用 json、form 标签中的名称表示的字段路径，如 "addresses[0].city"，用于返回给客户端
+	Path string
+	// StructField 字段的定义，可以从中获取 json、form 等标签
+	StructField reflect.StructField
+	Rule        string
+	Param       string
+	Value       interface{}
+	// Kind 字段的类型（指针已经解引用），用于区分 min、max 等规则比较的是长度还是数值
+	Kind reflect.Kind
+}
+
+func (e *FieldError) Error() string {
+	if e.Param != "" {
+		return fmt.Sprintf("binding: field '%s' failed on the '%s=%s' rule", e.Namespace, e.Rule, e.Param)
+	}
+	return fmt.Sprintf("binding: field '%s' failed on the '%s' rule", e.Namespace, e.Rule)
+}
+
+// ValidationErrors 校验失败的所有字段
+type ValidationErrors []*FieldError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// validate 使用 DefaultValidator 校验 obj
+func validate(obj interface{}) error {
+	if DefaultValidator == nil {
+		return nil
+	}
+	return DefaultValidator.ValidateStruct(obj)
+}
+
+// StructValidator 默认的 Validator 实现，通过 RegisterRule 添加自定义规则
+type StructValidator struct {
+	tag   string
+	mu    sync.RWMutex
+	rules map[string]RuleFunc
+	cache sync.Map // reflect.Type -> []fieldRules
+}
+
+// NewValidator 创建使用 binding 标签、包含内置规则的 StructValidator
+func NewValidator() *StructValidator {
+	v := &StructValidator{tag: "binding", rules: make(map[string]RuleFunc)}
+	for name, fn := range builtinRules {
+		v.rules[name] = fn
+	}
+	return v
+}
+
+// RegisterRule 注册自定义规则，已存在时会覆盖
+func (v *StructValidator) RegisterRule(name string, fn RuleFunc) {
+	if fn == nil {
+		panic("binding: RegisterRule rule is nil")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rules[name] = fn
+	// 缓存的规则在解析时就已经确定，清空后重新解析，使新注册的规则对已经校验过的类型同样生效
+	v.cache.Range(func(key, _ interface{}) bool {
+		v.cache.Delete(key)
+		return true
+	})
+}
+
+// RegisterRule 向 DefaultValidator 注册自定义规则，DefaultValidator 被替换成其他实现时会 panic
+func RegisterRule(name string, fn RuleFunc) {
+	v, ok := DefaultValidator.(*StructValidator)
+	if !ok {
+		panic("binding: DefaultValidator is not a *StructValidator")
+	}
+	v.RegisterRule(name, fn)
+}
+
+// ValidateStruct 校验结构体、结构体指针或者结构体的切片，其他类型直接返回 nil。
+// 嵌套的结构体、以及切片和 map 中的结构体元素会被递归校验，校验失败时返回 ValidationErrors
+func (v *StructValidator) ValidateStruct(obj interface{}) error {
+	value := reflect.ValueOf(obj)
+	if !value.IsValid() {
+		return nil
+	}
+	var errs ValidationErrors
+	v.validateValue(value, location{ns: indirectType(value.Type()).Name()}, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// rule 标签中的一条规则，如 "min=3"
+type rule struct {
+	name  string
+	param string
+	fn    RuleFunc
+}
+
+// fieldRules 结构体字段的规则，dive 之后的规则作用于切片或 map 的元素
+type fieldRules struct {
+	index     int
+	field     reflect.StructField
+	rules     []rule
+	omitEmpty bool
+	dive      *fieldRules
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// location 字段在结构体中的位置，ns 使用字段名，path 使用标签中的名称
+type location struct {
+	ns   string
+	path string
+}
+
+func (l location) field(field reflect.StructField) location {
+	name := fieldName(field)
+	if l.path != "" {
+		name = l.path + "." + name
+	}
+	if l.ns == "" {
+		return location{ns: field.Name, path: name}
+	}
+	return location{ns: l.ns + "." + field.Name, path: name}
+}
+
+func (l location) index(key interface{}) location {
+	suffix := fmt.Sprintf("[%v]", key)
+	return location{ns: l.ns + suffix, path: l.path + suffix}
+}
+
+// fieldName 返回字段在请求中的名称，依次使用 json、form 标签，都没有时使用字段名
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func (v *StructValidator) validateValue(value reflect.Value, loc location, errs *ValidationErrors) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type() == timeType {
+			return
+		}
+		v.validateStruct(value, loc, errs)
+	case reflect.Slice, reflect.Array:
+		// []byte、[]int 等元素中不可能有结构体，不需要逐个检查
+		if !canContainStruct(value.Type().Elem()) {
+			return
+		}
+		for i := 0; i < value.Len(); i++ {
+			v.validateValue(value.Index(i), loc.index(i), errs)
+		}
+	case reflect.Map:
+		if !canContainStruct(value.Type().Elem()) {
+			return
+		}
+		iter := value.MapRange()
+		for iter.Next() {
+			v.validateValue(iter.Value(), loc.index(interfaceOf(iter.Key())), errs)
+		}
+	}
+}
+
+// canContainStruct 判断 t 类型的值中是否可能有需要校验的结构体，interface 的实际类型未知，视为可能
+func canContainStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return t != timeType
+	case reflect.Interface:
+		return true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return canContainStruct(t.Elem())
+	}
+	return false
+}
+
+func (v *StructValidator) validateStruct(value reflect.Value, loc location, errs *ValidationErrors) {
+	for _, fr := range v.structRules(value.Type()) {
+		v.validateField(value.Field(fr.index), fr, loc.field(fr.field), errs)
+	}
+}
+
+func (v *StructValidator) validateField(value reflect.Value, fr fieldRules, loc location, errs *ValidationErrors) {
+	if fr.omitEmpty && isEmpty(value) {
+		return
+	}
+
+	// 指针为 nil 时只检查 required
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			for _, r := range fr.rules {
+				if r.name == "required" {
+					*errs = append(*errs, newFieldError(value, fr, r, loc))
+					break
+				}
+			}
+			return
+		}
+		value = value.Elem()
+	}
+
+	for _, r := range fr.rules {
+		if !r.fn(value, r.param) {
+			*errs = append(*errs, newFieldError(value, fr, r, loc))
+			// 同一个字段只报告第一条不满足的规则
+			return
+		}
+	}
+
+	if fr.dive != nil {
+		switch value.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < value.Len(); i++ {
+				v.validateField(value.Index(i), *fr.dive, loc.index(i), errs)
+			}
+		case reflect.Map:
+			iter := value.MapRange()
+			for iter.Next() {
+				v.validateField(iter.Value(), *fr.dive, loc.index(interfaceOf(iter.Key())), errs)
+			}
+		}
+		return
+	}
+	v.validateValue(value, loc, errs)
+}
+
+func newFieldError(value reflect.Value, fr fieldRules, r rule, loc location) *FieldError {
+	e := &FieldError{
+		Namespace:   loc.ns,
+		Field:       fr.field.Name,
+		Path:        loc.path,
+		StructField: fr.field,
+		Rule:        r.name,
+		Param:       r.param,
+		Kind:        value.Kind(),
+	}
+	if value.IsValid() && value.CanInterface() {
+		e.Value = value.Interface()
+	}
+	if value.Kind() == reflect.Ptr && !value.IsNil() {
+		e.Kind = value.Elem().Kind()
+	}
+	return e
+}
+
+// structRules 解析结构体各字段的规则，结果按类型缓存
+func (v *StructValidator) structRules(t reflect.Type) []fieldRules {
+	if cached, ok := v.cache.Load(t); ok {
+		return cached.([]fieldRules)
+	}
+
+	var frs []fieldRules
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// 未导出的字段只有嵌入的结构体需要校验（其中导出的字段会被提升）
+		if field.PkgPath != "" && !(field.Anonymous && isStructType(field.Type)) {
+			continue
+		}
+		tag := field.Tag.Get(v.tag)
+		if tag == "-" {
+			continue
+		}
+		fr := v.parseRules(field, strings.Split(tag, ","))
+		fr.index = i
+		frs = append(frs, fr)
+	}
+	v.cache.Store(t, frs)
+	return frs
+}
+
+func (v *StructValidator) parseRules(field reflect.StructField, names []string) fieldRules {
+	fr := fieldRules{field: field}
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "":
+			continue
+		case "omitempty":
+			fr.omitEmpty = true
+			continue
+		case "dive":
+			dive := v.parseRules(field, names[i+1:])
+			fr.dive = &dive
+			return fr
+		}
+
+		var param string
+		if j := strings.IndexByte(name, '='); j >= 0 {
+			name, param = name[:j], name[j+1:]
+		}
+		v.mu.RLock()
+		fn, ok := v.rules[name]
+		v.mu.RUnlock()
+		if !ok {
+			panic(fmt.Sprintf("binding: undefined validation rule '%s' on field '%s'", name, field.Name))
+		}
+		fr.rules = append(fr.rules, rule{name: name, param: param, fn: fn})
+	}
+	return fr
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+func isStructType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// interfaceOf 返回 value 的值。未导出的嵌入结构体中的字段不能调用 Interface，此时按 Kind 读取基础类型的值
+func interfaceOf(value reflect.Value) interface{} {
+	if value.CanInterface() {
+		return value.Interface()
+	}
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Bool:
+		return value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return value.Uint()
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	}
+	return nil
+}
+
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	}
+	return value.IsZero()
+}
+
+var (
+	emailRegex = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+	alphaRegex = regexp.MustCompile(`^[a-zA-Z]+$`)
+	alnumRegex = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+	numRegex   = regexp.MustCompile(`^[-+]?[0-9]+(?:\.[0-9]+)?$`)
+)
+
+var lenRule = compareRule(func(a, b float64) bool { return a == b })
+
+var builtinRules = map[string]RuleFunc{
+	"required": func(value reflect.Value, _ string) bool {
+		return !isEmpty(value)
+	},
+	"len": lenRule,
+	"min": compareRule(func(a, b float64) bool { return a >= b }),
+	"max": compareRule(func(a, b float64) bool { return a <= b }),
+	"eq": func(value reflect.Value, param string) bool {
+		return equal(value, param)
+	},
+	"ne": func(value reflect.Value, param string) bool {
+		return !equal(value, param)
+	},
+	"gt":  compareRule(func(a, b float64) bool { return a > b }),
+	"gte": compareRule(func(a, b float64) bool { return a >= b }),
+	"lt":  compareRule(func(a, b float64) bool { return a < b }),
+	"lte": compareRule(func(a, b float64) bool { return a <= b }),
+	"oneof": func(value reflect.Value, param string) bool {
+		s := fmt.Sprint(interfaceOf(value))
+		for _, option := range strings.Fields(param) {
+			if s == option {
+				return true
+			}
+		}
+		return false
+	},
+	"email":    regexRule(emailRegex),
+	"alpha":    regexRule(alphaRegex),
+	"alphanum": regexRule(alnumRegex),
+	"numeric":  regexRule(numRegex),
+	"url": func(value reflect.Value, _ string) bool {
+		if value.Kind() != reflect.String {
+			return false
+		}
+		u, err := url.Parse(value.String())
+		return err == nil && u.Scheme != "" && u.Host != ""
+	},
+}
+
+// compareRule 比较字段与参数：数值比较值，字符串比较字符数，切片、数组和 map 比较长度
+func compareRule(cmp func(a, b float64) bool) RuleFunc {
+	return func(value reflect.Value, param string) bool {
+		var n float64
+		switch value.Kind() {
+		case reflect.String:
+			n = float64(utf8.RuneCountInString(value.String()))
+		case reflect.Slice, reflect.Array, reflect.Map:
+			n = float64(value.Len())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if value.Type() == durationType {
+				d, err := time.ParseDuration(param)
+				if err != nil {
+					return false
+				}
+				return cmp(float64(value.Int()), float64(d))
+			}
+			n = float64(value.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			n = float64(value.Uint())
+		case reflect.Float32, reflect.Float64:
+			n = value.Float()
+		default:
+			return false
+		}
+
+		p, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false
+		}
+		return cmp(n, p)
+	}
+}
+
+// equal eq、ne 使用：字符串和布尔值直接与参数比较，其他类型与 len 相同
+func equal(value reflect.Value, param string) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return value.String() == param
+	case reflect.Bool:
+		b, err := strconv.ParseBool(param)
+		return err == nil && value.Bool() == b
+	}
+	return lenRule(value, param)
+}
+
+func regexRule(re *regexp.Regexp) RuleFunc {
+	return func(value reflect.Value, _ string) bool {
+		return value.Kind() == reflect.String && re.MatchString(value.String())
+	}
+}
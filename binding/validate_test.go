@@ -0,0 +1,263 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	City string `json:"city" binding:"required"`
+	Zip  string `json:"zip" binding:"omitempty,len=6,numeric"`
+}
+
+type member struct {
+	Name      string            `json:"name" binding:"required,min=3,max=64"`
+	Email     string            `json:"email" binding:"required,email"`
+	Role      string            `json:"role" binding:"oneof=admin user"`
+	Age       int               `json:"age" binding:"gt=0,lte=150"`
+	Nickname  *string           `json:"nickname" binding:"omitempty,alphanum"`
+	Home      *address          `json:"home" binding:"required"`
+	Addresses []address         `json:"addresses"`
+	Tags      []string          `json:"tags" binding:"max=3,dive,required,max=8"`
+	Labels    map[string]string `json:"labels" binding:"dive,ne=x"`
+}
+
+func validMember() member {
+	return member{
+		Name:  "knight",
+		Email: "knight@example.com",
+		Role:  "admin",
+		Age:   18,
+		Home:  &address{City: "Beijing"},
+	}
+}
+
+func rulesOf(err error) map[string]string {
+	rules := make(map[string]string)
+	for _, fe := range err.(ValidationErrors) {
+		rules[fe.Namespace] = fe.Rule
+	}
+	return rules
+}
+
+func TestValidateStruct(t *testing.T) {
+	m := validMember()
+	assert.Nil(t, DefaultValidator.ValidateStruct(&m))
+
+	nickname := "knight-7"
+	m = member{
+		Name:      "kn",
+		Email:     "knight",
+		Role:      "guest",
+		Nickname:  &nickname,
+		Addresses: []address{{City: "Beijing", Zip: "100000"}, {Zip: "1"}},
+		Tags:      []string{"a", "", "abcdefghi"},
+		Labels:    map[string]string{"k": "x"},
+	}
+	err := DefaultValidator.ValidateStruct(&m)
+	assert.Equal(t, map[string]string{
+		"member.Name":              "min",
+		"member.Email":             "email",
+		"member.Role":              "oneof",
+		"member.Age":               "gt",
+		"member.Nickname":          "alphanum",
+		"member.Home":              "required",
+		"member.Addresses[1].City": "required",
+		"member.Addresses[1].Zip":  "len",
+		"member.Tags[1]":           "required",
+		"member.Tags[2]":           "max",
+		"member.Labels[k]":         "ne",
+	}, rulesOf(err))
+
+	for _, fe := range err.(ValidationErrors) {
+		if fe.Field == "Name" {
+			assert.Equal(t, "3", fe.Param)
+			assert.Equal(t, "kn", fe.Value)
+			assert.Equal(t, reflect.String, fe.Kind)
+			assert.Equal(t, "name", fe.StructField.Tag.Get("json"))
+		}
+	}
+	assert.Contains(t, err.Error(), "binding: field 'member.Name' failed on the 'min=3' rule")
+
+	// 结构体切片中的每个元素都会被校验
+	members := []member{validMember(), {}}
+	err = DefaultValidator.ValidateStruct(&members)
+	assert.Equal(t, "required", rulesOf(err)["[1].Name"])
+
+	assert.Nil(t, DefaultValidator.ValidateStruct(nil))
+	assert.Nil(t, DefaultValidator.ValidateStruct("string"))
+}
+
+type audit struct {
+	Operator string            `json:"operator" binding:"required"`
+	Level    string            `json:"level" binding:"oneof=info warn"`
+	Timeout  time.Duration     `json:"timeout" binding:"lte=1m"`
+	Tags     map[string]string `json:"tags" binding:"dive,required"`
+	Created  time.Time
+}
+
+type token string
+
+func TestValidateStruct_UnexportedEmbedded(t *testing.T) {
+	type record struct {
+		audit
+		token
+		ID int `json:"id" binding:"required"`
+	}
+
+	r := record{ID: 1, audit: audit{Operator: "knight", Level: "info", Timeout: time.Second}, token: "x"}
+	assert.Nil(t, DefaultValidator.ValidateStruct(&r))
+
+	// 未导出的嵌入结构体中导出的字段同样会被校验
+	r.audit = audit{Level: "debug", Timeout: time.Hour, Tags: map[string]string{"k": ""}}
+	err := DefaultValidator.ValidateStruct(&r)
+	assert.Equal(t, map[string]string{
+		"record.audit.Operator": "required",
+		"record.audit.Level":    "oneof",
+		"record.audit.Timeout":  "lte",
+		"record.audit.Tags[k]":  "required",
+	}, rulesOf(err))
+}
+
+func TestRegisterRule(t *testing.T) {
+	v := NewValidator()
+	v.RegisterRule("prefix", func(value reflect.Value, param string) bool {
+		return strings.HasPrefix(value.String(), param)
+	})
+
+	type order struct {
+		ID string `binding:"prefix=ord_"`
+	}
+	assert.Nil(t, v.ValidateStruct(order{ID: "ord_1"}))
+	assert.Equal(t, map[string]string{"order.ID": "prefix"}, rulesOf(v.ValidateStruct(order{ID: "1"})))
+
+	type unknown struct {
+		ID string `binding:"unknown"`
+	}
+	assert.Panics(t, func() {
+		_ = v.ValidateStruct(unknown{})
+	})
+
+	// 类型校验过之后再注册或替换规则同样生效
+	v.RegisterRule("prefix", func(value reflect.Value, param string) bool {
+		return strings.HasSuffix(value.String(), param)
+	})
+	assert.Nil(t, v.ValidateStruct(order{ID: "1ord_"}))
+	assert.Panics(t, func() {
+		_ = v.ValidateStruct(unknown{})
+	})
+	v.RegisterRule("unknown", func(reflect.Value, string) bool { return false })
+	assert.Equal(t, map[string]string{"unknown.ID": "unknown"}, rulesOf(v.ValidateStruct(unknown{})))
+}
+
+func TestCanContainStruct(t *testing.T) {
+	assert.False(t, canContainStruct(reflect.TypeOf([]byte(nil))))
+	assert.False(t, canContainStruct(reflect.TypeOf(map[string][]int(nil))))
+	assert.False(t, canContainStruct(reflect.TypeOf([]time.Time(nil))))
+	assert.True(t, canContainStruct(reflect.TypeOf([]*address(nil))))
+	assert.True(t, canContainStruct(reflect.TypeOf(map[string][]interface{}(nil))))
+}
+
+func TestBindValidate(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"knight","email":"bad"}`))
+	var m member
+	err := JSON.Bind(req, &m)
+	assert.IsType(t, ValidationErrors{}, err)
+	assert.Equal(t, "knight", m.Name)
+
+	var f struct {
+		Age int `form:"age" binding:"min=18"`
+	}
+	req, _ = http.NewRequest(http.MethodGet, "/?age=17", nil)
+	assert.IsType(t, ValidationErrors{}, Form.Bind(req, &f))
+
+	// DefaultValidator 为 nil 时不做校验
+	DefaultValidator = nil
+	defer func() {
+		DefaultValidator = NewValidator()
+	}()
+	req, _ = http.NewRequest(http.MethodGet, "/?age=17", nil)
+	assert.Nil(t, Form.Bind(req, &f))
+}
+
+func TestTranslate(t *testing.T) {
+	m := validMember()
+	m.Name = "kn"
+	m.Age = 200
+	m.Role = "guest"
+	m.Tags = []string{"a", "b", "c", "d"}
+	m.Addresses = []address{{}}
+	errs := DefaultValidator.ValidateStruct(&m).(ValidationErrors)
+
+	assert.Equal(t, map[string]string{
+		"name":              "name must be at least 3 characters long",
+		"age":               "age must be at most 150",
+		"role":              "role must be one of [admin, user]",
+		"tags":              "tags must contain at most 3 items",
+		"addresses[0].city": "addresses[0].city is required",
+	}, errs.Translate("en-US"))
+
+	assert.Equal(t, map[string]string{
+		"name":              "name长度必须至少为3个字符",
+		"age":               "age必须小于或等于150",
+		"role":              "role必须是[admin, user]中的一个",
+		"tags":              "tags最多只能包含3项",
+		"addresses[0].city": "addresses[0].city为必填字段",
+	}, errs.Translate("zh_CN"))
+
+	// 没有注册的语言使用英文
+	assert.Equal(t, "name must be at least 3 characters long", errs[0].Translate("fr"))
+
+	// 自定义规则的错误信息
+	v := NewValidator()
+	v.RegisterRule("prefix", func(value reflect.Value, param string) bool {
+		return strings.HasPrefix(value.String(), param)
+	})
+	type order struct {
+		ID string `form:"order_id" binding:"prefix=ord_"`
+	}
+	fe := v.ValidateStruct(order{ID: "1"}).(ValidationErrors)[0]
+	assert.Equal(t, "order_id", fe.Path)
+	assert.Equal(t, "order_id failed on the prefix rule", fe.Translate("en"))
+
+	RegisterMessages("en", map[string]string{"prefix": "{field} must start with {param}"})
+	assert.Equal(t, "order_id must start with ord_", fe.Translate("en"))
+	assert.Equal(t, "order_id must start with ord_", fe.Translate("zh"))
+
+	locale, ok := MatchLocale("ZH-tw")
+	assert.True(t, ok)
+	assert.Equal(t, "zh", locale)
+}
+
+func TestTypeError(t *testing.T) {
+	var f struct {
+		Age    int  `form:"age"`
+		Active bool `form:"active"`
+	}
+	req, _ := http.NewRequest(http.MethodGet, "/?age=abc", nil)
+	fe := TypeError(Form.Bind(req, &f))
+	assert.Equal(t, "age", fe.Path)
+	assert.Equal(t, "type", fe.Rule)
+	assert.Equal(t, "age must be a number", fe.Translate("en"))
+	assert.Equal(t, "age必须是数值", fe.Translate("zh"))
+
+	req, _ = http.NewRequest(http.MethodGet, "/?active=maybe", nil)
+	assert.Equal(t, "active must be a boolean", TypeError(Form.Bind(req, &f)).Translate("en"))
+
+	var m member
+	req, _ = http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":1}`))
+	fe = TypeError(JSON.Bind(req, &m))
+	assert.Equal(t, "name", fe.Path)
+	assert.Equal(t, "name must be a string", fe.Translate("en"))
+
+	_, err := strconv.Atoi("x")
+	assert.Equal(t, "x must be a number", TypeError(err).Translate("en"))
+	assert.Nil(t, TypeError(errors.New("boom")))
+}
@@ -1,24 +1,30 @@
 package binding
 
 import (
+	"bytes"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"net/http"
 )
 
-type xmlBinding struct {}
+type xmlBinding struct{}
 
 func (b xmlBinding) Bind(req *http.Request, obj interface{}) error {
 	if req == nil || req.Body == nil {
 		return fmt.Errorf("invalid request")
 	}
-	return decodeXml(req, obj)
+	return decodeXml(req.Body, obj)
 }
 
-func decodeXml(req *http.Request, obj interface{}) error {
-	decoder := xml.NewDecoder(req.Body)
+func (b xmlBinding) BindBody(body []byte, obj interface{}) error {
+	return decodeXml(bytes.NewReader(body), obj)
+}
+
+func decodeXml(r io.Reader, obj interface{}) error {
+	decoder := xml.NewDecoder(r)
 	if err := decoder.Decode(obj); err != nil {
 		return err
 	}
-	return nil
+	return validate(obj)
 }
@@ -1,24 +1,30 @@
 package binding
 
 import (
+	"bytes"
 	"fmt"
 	"gopkg.in/yaml.v3"
+	"io"
 	"net/http"
 )
 
-type yamlBinding struct {}
+type yamlBinding struct{}
 
 func (b yamlBinding) Bind(req *http.Request, obj interface{}) error {
 	if req == nil || req.Body == nil {
 		return fmt.Errorf("invalid request")
 	}
-	return decodeYAML(req, obj)
+	return decodeYAML(req.Body, obj)
 }
 
-func decodeYAML(req *http.Request, obj interface{}) error {
-	decoder := yaml.NewDecoder(req.Body)
+func (b yamlBinding) BindBody(body []byte, obj interface{}) error {
+	return decodeYAML(bytes.NewReader(body), obj)
+}
+
+func decodeYAML(r io.Reader, obj interface{}) error {
+	decoder := yaml.NewDecoder(r)
 	if err := decoder.Decode(obj); err != nil {
 		return err
 	}
-	return nil
+	return validate(obj)
 }
@@ -0,0 +1,195 @@
+package gee
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// BufferedWriter 缓存响应的状态码和响应体，直到请求处理结束后才写入客户端，
+// 这样外层的中间件可以在 c.Next() 之后读取或改写响应（计算 ETag、压缩等）。
+// 响应体超过阈值时会先把缓存的内容写出，之后退化为直接写入
+type BufferedWriter struct {
+	ResponseWriter
+	buf       bytes.Buffer
+	status    int
+	written   bool
+	streaming bool
+	threshold int
+}
+
+// Buffered 开启缓存响应的中间件，可以通过 RouterGroup.Use 作用于整个分组，
+// 也可以通过 RouterGroup.Handle 只作用于单个路由。threshold <= 0 表示不限制缓存大小。
+// 需要读取响应的中间件要注册在 Buffered 之后，在 c.Next() 返回后通过 c.Writer.(*gee.BufferedWriter) 获取响应
+func Buffered(threshold int) HandlerFunc {
+	return func(c *Context) {
+		w := c.Writer
+		bw := &BufferedWriter{
+			ResponseWriter: w,
+			status:         w.Status(),
+			threshold:      threshold,
+		}
+		c.Writer = bw
+
+		header := w.Header().Clone()
+		completed := false
+		defer func() {
+			c.Writer = w
+			if completed {
+				bw.flush()
+				return
+			}
+			// 发生 panic 时丢弃缓存的响应和之后设置的响应头，交给外层的 Recovery 处理
+			if !bw.streaming {
+				restoreHeader(w.Header(), header)
+			}
+		}()
+
+		c.Next()
+		completed = true
+	}
+}
+
+func (w *BufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *BufferedWriter) Size() int {
+	if w.streaming {
+		return w.ResponseWriter.Size()
+	}
+	if !w.written {
+		return noWritten
+	}
+	return w.buf.Len()
+}
+
+func (w *BufferedWriter) Written() bool {
+	if w.streaming {
+		return w.ResponseWriter.Written()
+	}
+	return w.written
+}
+
+// Streaming 判断响应体是否超过阈值，已经退化为直接写入
+func (w *BufferedWriter) Streaming() bool {
+	return w.streaming
+}
+
+// Body 返回缓存的响应体，退化为直接写入后返回 nil
+func (w *BufferedWriter) Body() []byte {
+	if w.streaming {
+		return nil
+	}
+	return w.buf.Bytes()
+}
+
+// Reset 清空缓存的响应体，用于改写响应，状态码和响应头保持不变
+func (w *BufferedWriter) Reset() {
+	w.buf.Reset()
+}
+
+// discard 丢弃缓存的响应体，使响应回到未写入的状态，用于渲染失败时改为返回错误
+func (w *BufferedWriter) discard() {
+	w.buf.Reset()
+	w.written = false
+}
+
+// WriteHeader 缓存期间可以多次修改状态码
+func (w *BufferedWriter) WriteHeader(code int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *BufferedWriter) WriteHeaderNow() {
+	if w.streaming {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.written = true
+}
+
+func (w *BufferedWriter) Write(data []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.written = true
+	if w.threshold > 0 && w.buf.Len()+len(data) > w.threshold {
+		if err := w.stream(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(data)
+	}
+	return w.buf.Write(data)
+}
+
+// Flush 会立即把缓存的内容写出，并退化为直接写入
+func (w *BufferedWriter) Flush() {
+	_ = w.FlushError()
+}
+
+func (w *BufferedWriter) FlushError() error {
+	if !w.streaming {
+		if err := w.stream(); err != nil {
+			return err
+		}
+	}
+	return w.ResponseWriter.FlushError()
+}
+
+func (w *BufferedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.Hijack()
+	if err == nil {
+		w.streaming = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap 返回被包装的 ResponseWriter，http.ResponseController 会优先调用 BufferedWriter 的 FlushError，保证缓存的内容先被写出
+func (w *BufferedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// stream 把缓存的状态码和响应体写出，之后的写入直接交给底层的 ResponseWriter
+func (w *BufferedWriter) stream() error {
+	w.streaming = true
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.WriteHeaderNow()
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// flush 请求处理结束后写出缓存的响应，响应体完整时会设置 Content-Length
+func (w *BufferedWriter) flush() {
+	if w.streaming {
+		return
+	}
+	if w.buf.Len() > 0 && w.Header().Get("Content-Length") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(w.buf.Len()))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.written {
+		_ = w.stream()
+	}
+}
+
+func restoreHeader(dst, src http.Header) {
+	for key := range dst {
+		delete(dst, key)
+	}
+	for key, values := range src {
+		dst[key] = values
+	}
+}
@@ -1,11 +1,17 @@
 package gee
 
 import (
+	"bytes"
+	"errors"
 	"io"
+	"io/ioutil"
+	"log"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +23,10 @@ const (
 	defaultMaxMemory = 32 << 20
 )
 
+var (
+	ErrBodyTooLarge = errors.New("request body too large")
+)
+
 // Context 请求的上下文
 type Context struct {
 	Writer      ResponseWriter
@@ -30,6 +40,8 @@ type Context struct {
 	Keys        map[string]interface{}
 	mu          sync.RWMutex
 	sameSite    http.SameSite // cookie
+	body        []byte        // 缓存的请求体，见 RawBody
+	Errors      []error       // 见 Error
 }
 
 func (c *Context) reset(w http.ResponseWriter, r *http.Request) {
@@ -41,6 +53,8 @@ func (c *Context) reset(w http.ResponseWriter, r *http.Request) {
 	c.middlewares = nil
 	c.index = -1
 	c.Keys = nil
+	c.body = nil
+	c.Errors = nil
 }
 
 func (c *Context) Set(key string, value interface{}) {
@@ -81,6 +95,10 @@ func (c *Context) Next() {
 }
 
 func (c *Context) Fail(code int, err string) {
+	if c.engine.UseProblemDetails {
+		c.AbortWithProblem(NewProblem(code, err))
+		return
+	}
 	c.Abort()
 	c.JSON(code, err)
 }
@@ -91,6 +109,7 @@ func (c *Context) Abort() {
 
 func (c *Context) AbortWithStatus(code int) {
 	c.Status(code)
+	c.Writer.WriteHeaderNow()
 	c.Abort()
 }
 
@@ -106,6 +125,103 @@ func (c *Context) Param(key string) string {
 	return c.Params[key]
 }
 
+// RemoteIP 返回请求直接对端的 IP，即 Request.RemoteAddr 中的 IP 部分
+func (c *Context) RemoteIP() string {
+	ip := parseIP(c.Request.RemoteAddr)
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// ClientIP 返回客户端的真实 IP。只有当直接对端是可信代理时，才会依次读取
+// Forwarded、X-Forwarded-For、X-Real-IP 请求头，否则直接返回 RemoteIP
+func (c *Context) ClientIP() string {
+	remoteIP := c.RemoteIP()
+	if !c.fromTrustedProxy() {
+		return remoteIP
+	}
+
+	header := c.Request.Header
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		elements := parseForwarded(values)
+		chain := make([]string, 0, len(elements))
+		for _, element := range elements {
+			chain = append(chain, element.For)
+		}
+		if ip, ok := c.engine.clientIPFromChain(chain); ok {
+			return ip
+		}
+	}
+	if chain := splitHeader(header.Values("X-Forwarded-For")); len(chain) > 0 {
+		if ip, ok := c.engine.clientIPFromChain(chain); ok {
+			return ip
+		}
+	}
+	if ip := parseIP(header.Get("X-Real-IP")); ip != nil {
+		return ip.String()
+	}
+
+	return remoteIP
+}
+
+// Scheme 返回客户端请求使用的协议（"http" 或 "https"），
+// 直接对端是可信代理时会读取 Forwarded 的 proto 和 X-Forwarded-Proto 请求头（见 forwardedValue），
+// 其值不是 http 或 https 时忽略，改为根据是否为 TLS 连接判断
+func (c *Context) Scheme() string {
+	if c.fromTrustedProxy() {
+		proto := strings.ToLower(c.forwardedValue("X-Forwarded-Proto", func(e forwardedElement) string { return e.Proto }))
+		if proto == "http" || proto == "https" {
+			return proto
+		}
+	}
+
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host 返回客户端请求的 Host，
+// 直接对端是可信代理时会读取 Forwarded 的 host 和 X-Forwarded-Host 请求头（见 forwardedValue）
+func (c *Context) Host() string {
+	if c.fromTrustedProxy() {
+		if host := c.forwardedValue("X-Forwarded-Host", func(e forwardedElement) string { return e.Host }); host != "" {
+			return host
+		}
+	}
+	return c.Request.Host
+}
+
+// forwardedValue 返回由可信代理添加的值，Forwarded 优先于 X-Forwarded-* 请求头。
+// 代理会把值追加到请求头的末尾，最左边的值可能是客户端伪造的，所以从右往左查找：
+// Forwarded 最右边的元素由直接对端添加，元素的 for 是可信代理时，其左边的元素同样由可信代理添加，
+// 返回这些元素中最左边的非空值；X-Forwarded-* 无法判断每个值由谁添加，只使用最右边（直接对端添加）的值
+func (c *Context) forwardedValue(header string, get func(forwardedElement) string) string {
+	var value string
+	elements := parseForwarded(c.Request.Header.Values("Forwarded"))
+	for i := len(elements) - 1; i >= 0; i-- {
+		if v := get(elements[i]); v != "" {
+			value = v
+		}
+		if !c.engine.isTrustedProxy(parseIP(elements[i].For)) {
+			break
+		}
+	}
+	if value != "" {
+		return value
+	}
+
+	if values := splitHeader(c.Request.Header.Values(header)); len(values) > 0 {
+		return values[len(values)-1]
+	}
+	return ""
+}
+
+func (c *Context) fromTrustedProxy() bool {
+	return c.engine.isTrustedProxy(parseIP(c.Request.RemoteAddr))
+}
+
 func (c *Context) Status(code int) {
 	if code > 0 {
 		c.Writer.WriteHeader(code)
@@ -124,6 +240,52 @@ func (c *Context) JSON(code int, obj interface{}) {
 	c.Render(code, rendering.JSON{Data: obj})
 }
 
+// IndentedJSON 返回带缩进的 JSON，比 JSON 占用更多的 CPU 和带宽，建议只用于调试
+func (c *Context) IndentedJSON(code int, obj interface{}) {
+	c.Render(code, rendering.IndentedJSON{Data: obj})
+}
+
+// SecureJSON 返回 JSON 数组时在前面加上 Engine.SecureJSONPrefix，防止 JSON 劫持
+func (c *Context) SecureJSON(code int, obj interface{}) {
+	c.Render(code, rendering.SecureJSON{Prefix: c.engine.SecureJSONPrefix, Data: obj})
+}
+
+// JSONP 使用查询参数 callback 作为回调函数名返回 JSONP，没有 callback 时返回 JSON，
+// callback 不合法时返回 400
+func (c *Context) JSONP(code int, obj interface{}) {
+	callback := c.Query("callback")
+	if callback == "" {
+		c.JSON(code, obj)
+		return
+	}
+	if !rendering.IsValidCallback(callback) {
+		c.Fail(http.StatusBadRequest, "invalid callback")
+		return
+	}
+	c.Render(code, rendering.JSONP{Callback: callback, Data: obj})
+}
+
+// AsciiJSON 返回非 ASCII 字符被转义为 \uXXXX 的 JSON
+func (c *Context) AsciiJSON(code int, obj interface{}) {
+	c.Render(code, rendering.AsciiJSON{Data: obj})
+}
+
+// StreamJSON 与 JSON 相同，但直接编码到响应中，适合较大的数据，输出末尾带有换行
+func (c *Context) StreamJSON(code int, obj interface{}) {
+	c.Render(code, rendering.StreamJSON{Data: obj})
+}
+
+// PureJSON 返回不转义 HTML 字符的 JSON
+func (c *Context) PureJSON(code int, obj interface{}) {
+	c.Render(code, rendering.PureJSON{Data: obj})
+}
+
+// NDJSON 以 application/x-ndjson 逐条写出 records 中的记录，直到 records 被关闭。
+// 生产者应同时监听 c.Done()，在客户端断开连接时停止发送并关闭 records
+func (c *Context) NDJSON(code int, records <-chan interface{}) {
+	c.Render(code, rendering.NDJSON{Records: records})
+}
+
 func (c *Context) XML(code int, obj interface{}) {
 	c.Render(code, rendering.XML{Data: obj})
 }
@@ -132,6 +294,20 @@ func (c *Context) YAML(code int, obj interface{}) {
 	c.Render(code, rendering.YAML{Data: obj})
 }
 
+// StreamYAML 边编码边写入响应，适合较大的数据。编码失败时若已经写出部分内容，会中断连接而不是返回 500
+func (c *Context) StreamYAML(code int, obj interface{}) {
+	c.Render(code, rendering.StreamYAML{Data: obj})
+}
+
+func (c *Context) MsgPack(code int, obj interface{}) {
+	c.Render(code, rendering.MsgPack{Data: obj})
+}
+
+// CSV 逐行写出 CSV，rows 可以是 [][]string 或结构体切片，表头来自字段的 csv 标签
+func (c *Context) CSV(code int, rows interface{}) {
+	c.Render(code, rendering.CSV{Data: rows})
+}
+
 func (c *Context) Data(code int, contentType string, data []byte) {
 	c.Render(code, rendering.Data{ContentType: contentType, Data: data})
 }
@@ -145,27 +321,56 @@ func (c *Context) Redirect(code int, location string) {
 }
 
 func (c *Context) HTML(code int, name string, data interface{}) {
-	c.Render(code, rendering.HTML{
-		Name:     name,
-		Data:     data,
-		Template: c.engine.htmlTemplates,
-	})
+	if c.engine.HTMLRender == nil {
+		c.renderError(errors.New("HTML templates are not loaded"))
+		return
+	}
+	c.Render(code, c.engine.HTMLRender.Instance(name, data))
 }
 
 func (c *Context) Render(code int, r rendering.Render) {
+	c.Status(code)
+
 	if !c.bodyCanWriteContentWithStatus(code) {
 		r.WriteContentType(c.Writer)
+		c.Writer.WriteHeaderNow()
 		return
 	}
 
 	r.WriteContentType(c.Writer)
-	c.Status(code)
 
 	if err := r.Render(c.Writer); err != nil {
-		panic(err)
+		c.renderError(err)
 	}
 }
 
+// renderError 处理渲染失败：响应还未写入时改为返回 500；
+// 响应体已经部分写出时无法再修改，只能中断连接，让客户端知道响应不完整
+func (c *Context) renderError(err error) {
+	_ = c.Error(err)
+	c.Abort()
+
+	// 还在缓存中的部分响应没有发送给客户端，可以丢弃
+	if bw, ok := c.Writer.(*BufferedWriter); ok && !bw.Streaming() {
+		bw.discard()
+	}
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.Fail(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	log.Printf("[ERROR] %s %s: render failed after response was partially written: %v\n", c.Method, c.Path, err)
+	panic(http.ErrAbortHandler)
+}
+
+// Error 记录请求处理过程中发生的错误，Logger 会把它们一起输出
+func (c *Context) Error(err error) error {
+	assert1(err != nil, "err can not be nil")
+	c.Errors = append(c.Errors, err)
+	return err
+}
+
 func (c *Context) bodyCanWriteContentWithStatus(code int) bool {
 	switch {
 	// 状态码 1** 表示服务器收到消息，需要请求者继续操作
@@ -181,20 +386,39 @@ func (c *Context) bodyCanWriteContentWithStatus(code int) bool {
 	return true
 }
 
-func (c *Context) FormFile(filename string) (*multipart.FileHeader, error) {
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	files, err := c.FormFiles(name)
+	if err != nil {
+		return nil, err
+	}
+	return files[0], nil
+}
+
+// FormFiles 返回表单字段 name 下上传的所有文件。超过 Engine.MaxMultipartMemory 的部分会写入临时文件，
+// 文件大小超过 Engine.MaxUploadFileSize 或请求体超过 Engine.MaxUploadSize 时以 413 中止请求。
+// 注意 MaxUploadSize 在读取请求体时检查，而 MaxUploadFileSize 要等整个表单解析完（文件已经写入临时文件）后才检查，
+// 需要在接收过程中限制单个文件的大小时使用 StreamMultipart
+func (c *Context) FormFiles(name string) ([]*multipart.FileHeader, error) {
 	if c.Request.MultipartForm == nil {
-		if err := c.Request.ParseMultipartForm(defaultMaxMemory); err != nil {
-			return nil, err
+		c.limitBody()
+		if err := c.Request.ParseMultipartForm(c.engine.MaxMultipartMemory); err != nil {
+			return nil, c.multipartError(err)
 		}
 	}
 
-	file, fh, err := c.Request.FormFile(filename)
-	if err != nil {
-		return nil, err
+	files := c.Request.MultipartForm.File[name]
+	if len(files) == 0 {
+		return nil, http.ErrMissingFile
+	}
+	if limit := c.engine.MaxUploadFileSize; limit > 0 {
+		for _, fh := range files {
+			if fh.Size > limit {
+				return nil, c.multipartError(ErrFileTooLarge)
+			}
+		}
 	}
-	_ = file.Close()
 
-	return fh, err
+	return files, nil
 }
 
 func (c *Context) SaveFile(file *multipart.FileHeader, dst string) error {
@@ -214,6 +438,29 @@ func (c *Context) SaveFile(file *multipart.FileHeader, dst string) error {
 	return err
 }
 
+// SafeSaveFile 将文件保存到 Engine.UploadRoot 下的相对路径 dst 中，
+// dst 跳出 UploadRoot（如 "../../etc/passwd"）时返回 ErrInvalidUploadPath
+func (c *Context) SafeSaveFile(file *multipart.FileHeader, dst string) error {
+	if c.engine.UploadRoot == "" {
+		return errors.New("upload root is not configured")
+	}
+	root, err := filepath.Abs(c.engine.UploadRoot)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(root, dst)
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ErrInvalidUploadPath
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return err
+	}
+	return c.SaveFile(file, target)
+}
+
 func (c *Context) File(filepath string) {
 	http.ServeFile(c.Writer, c.Request, filepath)
 }
@@ -271,10 +518,41 @@ func (c *Context) BindYAML(obj interface{}) error {
 	return c.MustBindWith(obj, binding.YAML)
 }
 
+func (c *Context) BindMsgPack(obj interface{}) error {
+	return c.MustBindWith(obj, binding.MsgPack)
+}
+
+func (c *Context) BindCSV(obj interface{}) error {
+	return c.MustBindWith(obj, binding.CSV)
+}
+
 func (c *Context) BindURL(obj interface{}) error {
 	return c.MustBindWith(obj, binding.Form)
 }
 
+// BindHeader 通过 header 标签将请求头解析到 obj 中，失败时返回 400
+func (c *Context) BindHeader(obj interface{}) error {
+	return c.MustBindWith(obj, binding.Header)
+}
+
+// BindURI 将路由参数解析到 obj 中，失败时返回 400
+func (c *Context) BindURI(obj interface{}) error {
+	if err := c.ShouldBindURI(obj); err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return err
+	}
+	return nil
+}
+
+// ShouldBindURI 通过 uri 标签将路由参数解析到 obj 中，如 `uri:"id"` 对应路由中的 ":id"
+func (c *Context) ShouldBindURI(obj interface{}) error {
+	params := make(map[string][]string, len(c.Params))
+	for key, value := range c.Params {
+		params[key] = []string{value}
+	}
+	return binding.URI.BindURI(params, obj)
+}
+
 func (c *Context) MustBindWith(obj interface{}, b binding.Binder) error {
 	if err := c.ShouldBindWith(obj, b); err != nil {
 		c.AbortWithStatus(http.StatusBadRequest)
@@ -287,18 +565,73 @@ func (c *Context) ShouldBindWith(obj interface{}, b binding.Binder) error {
 	return b.Bind(c.Request, obj)
 }
 
+// ShouldBindBodyWith 与 ShouldBindWith 类似，但会把请求体缓存在 Context 中，
+// 因此可以在中间件和处理函数中多次调用，用不同的 BodyBinder 解析同一个请求体
+func (c *Context) ShouldBindBodyWith(obj interface{}, bb binding.BodyBinder) error {
+	body, err := c.RawBody()
+	if err != nil {
+		return err
+	}
+	return bb.BindBody(body, obj)
+}
+
+// RawBody 读取并缓存请求体，之后 Request.Body 会被替换成缓存内容的 Reader，
+// 所以读取后的 Bind 依然可以正常工作。请求体超过 Engine.MaxBodyCacheSize（<= 0 表示不限制）时返回 ErrBodyTooLarge，
+// 此时 Request.Body 保持完整但不会被缓存
+func (c *Context) RawBody() ([]byte, error) {
+	if c.body != nil {
+		return c.body, nil
+	}
+	if c.Request.Body == nil {
+		return nil, errors.New("invalid request")
+	}
+
+	var reader io.Reader = c.Request.Body
+	limit := c.engine.MaxBodyCacheSize
+	if limit > 0 {
+		reader = io.LimitReader(reader, limit+1)
+	}
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(body)) > limit {
+		// 放回已读取的部分，之后仍可以按流的方式读取完整的请求体
+		c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+		return nil, ErrBodyTooLarge
+	}
+
+	c.body = body
+	c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Deadline、Done、Err、Value 使 Context 实现 context.Context，
+// 取消和超时来自请求的 context，客户端断开连接时 Done 会被关闭
+
 func (c *Context) Deadline() (deadline time.Time, ok bool) {
-	return
+	return c.Request.Context().Deadline()
 }
 
 func (c *Context) Done() <-chan struct{} {
-	return nil
+	return c.Request.Context().Done()
 }
 
 func (c *Context) Err() error {
-	return nil
+	return c.Request.Context().Err()
 }
 
+// Value 优先查找通过 Set 保存的值，其次查找请求的 context
 func (c *Context) Value(key interface{}) interface{} {
-	return nil
+	if keyAsString, ok := key.(string); ok {
+		if val, exists := c.Get(keyAsString); exists {
+			return val
+		}
+	}
+	return c.Request.Context().Value(key)
 }
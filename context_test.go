@@ -1,10 +1,21 @@
 package gee
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"github.com/Knight-7/gee/binding"
+	"github.com/Knight-7/gee/rendering"
 	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -138,3 +149,679 @@ func TestContext_File(t *testing.T) {
 	}
 	engine.Run(":2020")
 }
+
+func TestContext_ClientIP(t *testing.T) {
+	engine := New()
+	assert.Nil(t, engine.SetTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"}))
+	assert.NotNil(t, engine.SetTrustedProxies([]string{"10.0.0.0/33"}))
+	assert.Nil(t, engine.SetTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"}))
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.168.1.1:8080"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1, 2.2.2.2, 10.0.0.2")
+	req.Header.Set("X-Real-IP", "3.3.3.3")
+	c := setUpContext(engine, httptest.NewRecorder(), req)
+	assert.Equal(t, "2.2.2.2", c.ClientIP())
+
+	req.Header.Set("Forwarded", `for=1.1.1.1, for="[2001:db8:cafe::17]:4711";proto=https;host=example.com`)
+	assert.Equal(t, "2001:db8:cafe::17", c.ClientIP())
+	assert.Equal(t, "https", c.Scheme())
+	assert.Equal(t, "example.com", c.Host())
+
+	// 客户端伪造的 host 和 proto 在左边，只使用可信代理添加的值
+	req.Header.Set("Forwarded", `for=10.0.0.9;proto=http;host=evil.com, for=1.1.1.1;proto=https;host=example.com`)
+	assert.Equal(t, "https", c.Scheme())
+	assert.Equal(t, "example.com", c.Host())
+	// 经过多层可信代理时，使用最外层可信代理添加的值
+	req.Header.Set("Forwarded", `for=1.1.1.1;proto=https;host=example.com, for=10.0.0.2`)
+	assert.Equal(t, "https", c.Scheme())
+	assert.Equal(t, "example.com", c.Host())
+	req.Header.Del("Forwarded")
+	req.Header.Set("X-Forwarded-Host", "evil.com, example.com")
+	req.Header.Set("X-Forwarded-Proto", "http, https")
+	assert.Equal(t, "https", c.Scheme())
+	assert.Equal(t, "example.com", c.Host())
+	req.Header.Del("X-Forwarded-Host")
+	req.Header.Del("X-Forwarded-Proto")
+
+	req.Header.Del("Forwarded")
+	req.Header.Del("X-Forwarded-For")
+	assert.Equal(t, "3.3.3.3", c.ClientIP())
+
+	// 只接受 http 和 https，其他值时根据是否为 TLS 连接判断
+	req.Header.Set("X-Forwarded-Proto", "HTTPS")
+	assert.Equal(t, "https", c.Scheme())
+	req.Header.Set("X-Forwarded-Proto", "javascript")
+	assert.Equal(t, "http", c.Scheme())
+	req.TLS = &tls.ConnectionState{}
+	assert.Equal(t, "https", c.Scheme())
+	req.TLS = nil
+	req.Header.Del("X-Forwarded-Proto")
+
+	// 直接对端不可信时，忽略所有代理请求头
+	req.RemoteAddr = "4.4.4.4:1234"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "example.com")
+	req.Host = "origin.com"
+	assert.Equal(t, "4.4.4.4", c.ClientIP())
+	assert.Equal(t, "http", c.Scheme())
+	assert.Equal(t, "origin.com", c.Host())
+}
+
+func TestContext_ShouldBindBodyWith(t *testing.T) {
+	engine := New()
+	engine.Use(func(c *Context) {
+		var user User
+		assert.Nil(t, c.ShouldBindBodyWith(&user, binding.JSON))
+		assert.Equal(t, "knight", user.Name)
+		c.Next()
+	})
+	engine.POST("/user", func(c *Context) {
+		var user1, user2 User
+		assert.Nil(t, c.ShouldBindBodyWith(&user1, binding.JSON))
+		assert.Nil(t, c.BindJSON(&user2))
+		assert.Equal(t, user1, user2)
+		c.JSON(http.StatusOK, user1)
+	})
+
+	body := `{"name":"knight","password":"123","age":18,"male":true}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/user", strings.NewReader(body))
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+
+	engine.MaxBodyCacheSize = 8
+	req, _ = http.NewRequest(http.MethodPost, "/user", strings.NewReader(body))
+	c := setUpContext(engine, httptest.NewRecorder(), req)
+	var user User
+	assert.Equal(t, ErrBodyTooLarge, c.ShouldBindBodyWith(&user, binding.JSON))
+	// 超过限制时请求体不会丢失
+	assert.Nil(t, c.BindJSON(&user))
+	assert.Equal(t, "knight", user.Name)
+
+	// <= 0 表示不限制
+	engine.MaxBodyCacheSize = 0
+	req, _ = http.NewRequest(http.MethodPost, "/user", strings.NewReader(body))
+	c = setUpContext(engine, httptest.NewRecorder(), req)
+	user = User{}
+	assert.Nil(t, c.ShouldBindBodyWith(&user, binding.JSON))
+	assert.Equal(t, "knight", user.Name)
+}
+
+func newMultipartRequest(t *testing.T, files map[string][]string) *http.Request {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	assert.Nil(t, mw.WriteField("name", "knight"))
+	for field, contents := range files {
+		for i, content := range contents {
+			fw, err := mw.CreateFormFile(field, fmt.Sprintf("%s%d.txt", field, i))
+			assert.Nil(t, err)
+			_, _ = fw.Write([]byte(content))
+		}
+	}
+	assert.Nil(t, mw.Close())
+
+	req, _ := http.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestContext_FormFiles(t *testing.T) {
+	engine := New()
+	req := newMultipartRequest(t, map[string][]string{"files": {"hello", "world"}})
+	c := setUpContext(engine, httptest.NewRecorder(), req)
+	files, err := c.FormFiles("files")
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+	_, err = c.FormFile("missing")
+	assert.Equal(t, http.ErrMissingFile, err)
+
+	engine.MaxUploadFileSize = 4
+	w := httptest.NewRecorder()
+	c = setUpContext(engine, w, newMultipartRequest(t, map[string][]string{"files": {"hello"}}))
+	_, err = c.FormFile("files")
+	assert.Equal(t, ErrFileTooLarge, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// 请求体超过 MaxUploadSize 时在解析过程中中止
+	engine.MaxUploadFileSize = 0
+	engine.MaxUploadSize = 64
+	w = httptest.NewRecorder()
+	c = setUpContext(engine, w, newMultipartRequest(t, map[string][]string{"files": {strings.Repeat("a", 128)}}))
+	_, err = c.FormFiles("files")
+	assert.True(t, errors.Is(err, ErrBodyTooLarge))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestContext_StreamMultipart(t *testing.T) {
+	engine := New()
+	req := newMultipartRequest(t, map[string][]string{"file": {"hello"}})
+	c := setUpContext(engine, httptest.NewRecorder(), req)
+	parts := make(map[string]string)
+	err := c.StreamMultipart(func(part *MultipartPart) error {
+		data, err := ioutil.ReadAll(part)
+		parts[part.FormName()] = string(data)
+		return err
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"name": "knight", "file": "hello"}, parts)
+
+	engine.MaxUploadFileSize = 4
+	w := httptest.NewRecorder()
+	c = setUpContext(engine, w, newMultipartRequest(t, map[string][]string{"file": {"hello"}}))
+	err = c.StreamMultipart(func(part *MultipartPart) error {
+		_, err := ioutil.ReadAll(part)
+		return err
+	})
+	assert.Equal(t, ErrFileTooLarge, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	engine.MaxUploadFileSize = 0
+	engine.MaxUploadSize = 64
+	w = httptest.NewRecorder()
+	c = setUpContext(engine, w, newMultipartRequest(t, map[string][]string{"file": {strings.Repeat("a", 128)}}))
+	err = c.StreamMultipart(func(part *MultipartPart) error {
+		_, err := ioutil.ReadAll(part)
+		return err
+	})
+	assert.True(t, errors.Is(err, ErrBodyTooLarge))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestContext_SafeSaveFile(t *testing.T) {
+	engine := New()
+	engine.UploadRoot = t.TempDir()
+	c := setUpContext(engine, httptest.NewRecorder(), newMultipartRequest(t, map[string][]string{"file": {"hello"}}))
+	fh, err := c.FormFile("file")
+	assert.Nil(t, err)
+
+	assert.Nil(t, c.SafeSaveFile(fh, "a/b.txt"))
+	data, err := ioutil.ReadFile(filepath.Join(engine.UploadRoot, "a", "b.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	assert.Equal(t, ErrInvalidUploadPath, c.SafeSaveFile(fh, "../b.txt"))
+	assert.Equal(t, ErrInvalidUploadPath, c.SafeSaveFile(fh, "a/../../b.txt"))
+}
+
+func TestContext_SignedAndEncryptedCookie(t *testing.T) {
+	oldKey := []byte("0123456789abcdef-old")
+	newKey := []byte("0123456789abcdef-new")
+
+	engine := New()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	c := setUpContext(engine, httptest.NewRecorder(), req)
+	assert.Equal(t, ErrNoCookieKeys, c.SetSignedCookie("user", "knight", 3600, "/", "", false, true))
+	assert.NotNil(t, engine.SetCookieKeys([]byte("short")))
+	assert.Nil(t, engine.SetCookieKeys(oldKey))
+
+	w := httptest.NewRecorder()
+	c = setUpContext(engine, w, req)
+	assert.Nil(t, c.SetSignedCookie("user", "knight", 3600, "/", "", false, true))
+	assert.Nil(t, c.SetEncryptedCookie("secret", "knight", 3600, "/", "", false, true))
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 2)
+	assert.NotContains(t, cookies[1].Value, "knight")
+
+	// 轮换密钥后，使用旧密钥签名和加密的 Cookie 依然有效
+	assert.Nil(t, engine.SetCookieKeys(newKey, oldKey))
+	req, _ = http.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	c = setUpContext(engine, httptest.NewRecorder(), req)
+	val, err := c.SignedCookie("user")
+	assert.Nil(t, err)
+	assert.Equal(t, "knight", val)
+	val, err = c.EncryptedCookie("secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "knight", val)
+
+	// 旧密钥被移除后校验失败
+	assert.Nil(t, engine.SetCookieKeys(newKey))
+	_, err = c.SignedCookie("user")
+	assert.Equal(t, ErrInvalidCookie, err)
+	_, err = c.EncryptedCookie("secret")
+	assert.Equal(t, ErrInvalidCookie, err)
+
+	// 篡改或挪用到其他 Cookie 名时校验失败
+	req, _ = http.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "user", Value: "a25pZ2h0.AAAA"})
+	req.AddCookie(&http.Cookie{Name: "other", Value: cookies[0].Value})
+	c = setUpContext(engine, httptest.NewRecorder(), req)
+	_, err = c.SignedCookie("user")
+	assert.Equal(t, ErrInvalidCookie, err)
+	assert.Nil(t, engine.SetCookieKeys(oldKey))
+	_, err = c.SignedCookie("other")
+	assert.Equal(t, ErrInvalidCookie, err)
+}
+
+func TestContext_Problem(t *testing.T) {
+	engine := Default()
+	engine.UseProblemDetails = true
+	engine.HandleMethodNotAllowed = true
+	engine.GET("/problem", func(c *Context) {
+		c.AbortWithProblem(&Problem{
+			Type:       "https://example.com/probs/out-of-credit",
+			Title:      "You do not have enough credit.",
+			Status:     http.StatusForbidden,
+			Extensions: map[string]interface{}{"balance": 30},
+		})
+	})
+	engine.GET("/panic", func(c *Context) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/problem", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "application/problem+json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.","status":403,"balance":30}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/panic", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/missing", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"detail":"No route for /missing"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/problem", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET", w.Header().Get("Allow"))
+	assert.Equal(t, "application/problem+json; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func TestContext_Done(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	c := setUpContext(New(), httptest.NewRecorder(), req)
+	c.Set("user", "knight")
+	assert.Equal(t, "knight", c.Value("user"))
+	assert.Nil(t, c.Err())
+
+	cancel()
+	<-c.Done()
+	assert.Equal(t, context.Canceled, c.Err())
+}
+
+func TestContext_JSONP(t *testing.T) {
+	engine := New()
+	engine.GET("/jsonp", func(c *Context) {
+		c.JSONP(http.StatusOK, H{"foo": "bar"})
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, `/**/cb({"foo":"bar"});`, serve("/jsonp?callback=cb").Body.String())
+	assert.Equal(t, `{"foo":"bar"}`, serve("/jsonp").Body.String())
+	assert.Equal(t, http.StatusBadRequest, serve("/jsonp?callback=alert(1)").Code)
+}
+
+func TestContext_Negotiate(t *testing.T) {
+	rendering.Register("application/vnd.gee+json", func(data interface{}) rendering.Render {
+		return rendering.JSON{Data: data}
+	})
+	t.Cleanup(func() {
+		rendering.Unregister("application/vnd.gee+json")
+	})
+
+	engine := New()
+	engine.GET("/user", func(c *Context) {
+		c.Negotiate(http.StatusOK, H{"name": "knight"})
+	})
+	engine.GET("/only", func(c *Context) {
+		c.Negotiate(http.StatusOK, H{"name": "knight"}, "application/json", "application/xml")
+	})
+
+	serve := func(path, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/user", "")
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = serve("/user", "text/html, application/xml;q=0.9, */*;q=0.8")
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<map><name>knight</name></map>", w.Body.String())
+
+	w = serve("/user", "application/vnd.gee+json")
+	assert.Equal(t, "application/vnd.gee+json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"name":"knight"}`, w.Body.String())
+
+	w = serve("/user", "text/*")
+	assert.Equal(t, "text/xml; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = serve("/only", "application/x-yaml")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	// q=0 表示不接受，即使 */* 也能匹配
+	w = serve("/only", "application/json;q=0, */*;q=0.1")
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	w = serve("/only", "*/*, application/*;q=0")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+}
+
+func TestContext_MsgPack(t *testing.T) {
+	user := User{Name: "knight", Password: "123", Age: 18, Male: true}
+
+	engine := New()
+	engine.POST("/user", func(c *Context) {
+		var u User
+		if err := c.Bind(&u); err != nil {
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.MsgPack(http.StatusOK, u)
+	})
+
+	var body bytes.Buffer
+	encoder := msgpack.NewEncoder(&body)
+	encoder.SetCustomStructTag("json")
+	assert.Nil(t, encoder.Encode(user))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/user", &body)
+	req.Header.Set("Content-Type", binding.MIMEMSGPACK2)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+
+	// 没有 msgpack 标签时使用 json 标签
+	var m map[string]interface{}
+	assert.Nil(t, msgpack.Unmarshal(w.Body.Bytes(), &m))
+	assert.Equal(t, "knight", m["name"])
+
+	var u User
+	assert.Nil(t, binding.MsgPack.BindBody(w.Body.Bytes(), &u))
+	assert.Equal(t, user, u)
+}
+
+type failingYAML struct{}
+
+func (failingYAML) MarshalYAML() (interface{}, error) {
+	return nil, errors.New("boom")
+}
+
+func TestContext_Stream(t *testing.T) {
+	large := make(map[string]interface{})
+	for i := 0; i < 1000; i++ {
+		large[fmt.Sprintf("key%04d", i)] = "value"
+	}
+
+	engine := New()
+	engine.GET("/json", func(c *Context) {
+		c.StreamJSON(http.StatusOK, H{"name": "knight"})
+	})
+	engine.GET("/yaml", func(c *Context) {
+		c.StreamYAML(http.StatusOK, large)
+	})
+	engine.GET("/broken", func(c *Context) {
+		large["zzz"] = failingYAML{}
+		c.StreamYAML(http.StatusOK, large)
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/json")
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"name\":\"knight\"}\n", w.Body.String())
+
+	w = serve("/yaml")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "key0000: value\n"))
+
+	// 已经写出部分内容后编码失败，只能中断连接
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		serve("/broken")
+	})
+}
+
+func TestContext_RenderError(t *testing.T) {
+	engine := Default()
+	engine.GET("/json", func(c *Context) {
+		c.JSON(http.StatusOK, H{"ch": make(chan int)})
+	})
+	engine.GET("/xml", func(c *Context) {
+		c.XML(http.StatusOK, H{"ch": make(chan int)})
+	})
+	engine.GET("/partial", func(c *Context) {
+		c.String(http.StatusOK, "partial")
+		c.renderError(errors.New("boom"))
+	})
+
+	for _, path := range []string{"/json", "/xml"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, `"Internal Server Error"`, w.Body.String())
+	}
+
+	// 响应已经部分写出时中断连接
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/partial", nil)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		engine.ServeHTTP(w, req)
+	})
+	assert.Equal(t, "partial", w.Body.String())
+
+	c := setUpContext(engine, httptest.NewRecorder(), req)
+	c.JSON(http.StatusOK, make(chan int))
+	assert.Len(t, c.Errors, 1)
+}
+
+func TestContext_HTMLRender(t *testing.T) {
+	emails := rendering.NewTextTemplates(nil)
+	assert.Nil(t, emails.AddFromFiles("welcome", "rendering/testdata/templates/email/welcome.txt"))
+
+	engine := New()
+	engine.GET("/index/:name", func(c *Context) {
+		c.HTML(http.StatusOK, "index.html", H{"title": "index", "body": c.Param("name")})
+	})
+	engine.GET("/email", func(c *Context) {
+		c.HTML(http.StatusOK, "welcome", H{"user": "knight"})
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	// 没有加载模板时返回 500
+	assert.Equal(t, http.StatusInternalServerError, serve("/index/knight").Code)
+
+	// 调试模式下默认开启自动重新加载
+	assert.True(t, engine.HTMLAutoReload)
+	engine.LoadHTMLFiles("testdata/static/index.html")
+	assert.IsType(t, &rendering.HTMLDebug{}, engine.HTMLRender)
+	w := serve("/index/knight")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<title>index</title>")
+
+	// 关闭后使用预编译的模板
+	engine.HTMLAutoReload = false
+	engine.LoadHTMLFiles("testdata/static/index.html")
+	assert.IsType(t, rendering.HTMLProduction{}, engine.HTMLRender)
+	assert.Contains(t, serve("/index/knight").Body.String(), "<title>index</title>")
+
+	// 生产模式下默认关闭
+	SetMode(ReleaseMode)
+	assert.False(t, New().HTMLAutoReload)
+	SetMode(DebugMode)
+
+	// 无论是否开启，加载时模板出错都会 panic
+	assert.Panics(t, func() {
+		engine.LoadHTMLGlob("testdata/static/missing-*.html")
+	})
+	engine.HTMLAutoReload = true
+	assert.Panics(t, func() {
+		engine.LoadHTMLFiles("testdata/static/missing.html")
+	})
+
+	// 替换成其他模板引擎
+	engine.HTMLRender = emails
+	w = serve("/email")
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Hello knight, welcome to <gee>!\n", w.Body.String())
+}
+
+func TestContext_ValidationMessages(t *testing.T) {
+	type signup struct {
+		Name string `json:"name" form:"name" binding:"required"`
+		Age  int    `json:"age" form:"age" binding:"min=18"`
+	}
+
+	engine := New()
+	engine.POST("/signup", E(func(c *Context) error {
+		var s signup
+		if err := c.ShouldBindWith(&s, binding.JSON); err != nil {
+			return err
+		}
+		c.String(http.StatusOK, s.Name)
+		return nil
+	}))
+
+	engine.GET("/signup", E(func(c *Context) error {
+		var s signup
+		if err := c.ShouldBindWith(&s, binding.Form); err != nil {
+			return err
+		}
+		c.String(http.StatusOK, s.Name)
+		return nil
+	}))
+
+	serveBody := func(lang, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/signup", strings.NewReader(body))
+		req.Header.Set("Accept-Language", lang)
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	serve := func(lang string) *httptest.ResponseRecorder {
+		return serveBody(lang, `{"age":16}`)
+	}
+
+	w := serve("fr-FR, en;q=0.8")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"errors":{"name":"name is required","age":"age must be at least 18"}}`, w.Body.String())
+
+	w = serve("zh-CN,zh;q=0.9,en;q=0.8")
+	assert.JSONEq(t, `{"errors":{"name":"name为必填字段","age":"age必须大于或等于18"}}`, w.Body.String())
+
+	// 类型转换失败同样返回 400 和客户端语言的错误信息
+	w = serveBody("en", `{"name":"knight","age":"18"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"errors":{"age":"age must be a number"}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/signup?name=knight&age=abc", nil)
+	req.Header.Set("Accept-Language", "zh-CN")
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"errors":{"age":"age必须是数值"}}`, w.Body.String())
+
+	engine.UseProblemDetails = true
+	w = serve("")
+	assert.Equal(t, "application/problem+json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"age":"age must be at least 18"`)
+}
+
+func TestContext_Locale(t *testing.T) {
+	locale := func(lang string) string {
+		c := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+		c.Request.Header.Set("Accept-Language", lang)
+		return c.Locale()
+	}
+
+	assert.Equal(t, "zh", locale("en;q=0.5, zh;q=0.9"))
+	assert.Equal(t, "en", locale("zh-CN;q=0.8, en"))
+	// q 值相同时按请求头顺序
+	assert.Equal(t, "zh", locale("fr, zh-CN, en"))
+	// q=0 的语言不会被匹配
+	assert.Equal(t, binding.DefaultLocale, locale("zh;q=0, fr"))
+	assert.Equal(t, binding.DefaultLocale, locale(""))
+}
+
+func TestContext_BindURI(t *testing.T) {
+	type post struct {
+		ID   int    `uri:"id" binding:"required"`
+		Slug string `uri:"slug" binding:"required,alphanum"`
+	}
+
+	engine := New()
+	engine.GET("/user/:id/:slug", func(c *Context) {
+		var p post
+		if err := c.BindURI(&p); err != nil {
+			return
+		}
+		c.JSON(http.StatusOK, H{"id": p.ID, "slug": p.Slug})
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/user/7/gee")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":7,"slug":"gee"}`, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, serve("/user/knight/gee").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("/user/7/hello-gee").Code)
+}
+
+func TestContext_BindHeader(t *testing.T) {
+	type headers struct {
+		Tenant string `header:"X-Tenant-ID" binding:"required"`
+	}
+
+	engine := New()
+	engine.GET("/tenant", func(c *Context) {
+		var h headers
+		if err := c.BindHeader(&h); err != nil {
+			return
+		}
+		c.String(http.StatusOK, h.Tenant)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/tenant", nil)
+	req.Header.Set("x-tenant-id", "acme")
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, "acme", w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/tenant", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
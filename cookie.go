@@ -0,0 +1,173 @@
+package gee
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrInvalidCookie = errors.New("invalid cookie")
+	ErrNoCookieKeys  = errors.New("cookie keys are not configured")
+)
+
+// keyRing 签名和加密 Cookie 使用的密钥环。第一个密钥是最新的密钥，用于签名和加密；
+// 校验和解密时会依次尝试所有密钥，这样轮换密钥后旧的 Cookie 依然有效
+type keyRing struct {
+	signKeys [][]byte
+	aeads    []cipher.AEAD
+}
+
+// SetCookieKeys 设置 Cookie 密钥环，keys[0] 为最新的密钥。
+// 轮换密钥时把新密钥放在最前面，并保留旧密钥直到旧的 Cookie 全部过期
+func (engine *Engine) SetCookieKeys(keys ...[]byte) error {
+	ring := &keyRing{}
+	for _, key := range keys {
+		if len(key) < 16 {
+			return errors.New("cookie key must be at least 16 bytes")
+		}
+
+		// 签名和加密使用从同一个密钥派生出的不同子密钥
+		ring.signKeys = append(ring.signKeys, deriveKey(key, "gee-cookie-sign"))
+		block, err := aes.NewCipher(deriveKey(key, "gee-cookie-encrypt"))
+		if err != nil {
+			return err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return err
+		}
+		ring.aeads = append(ring.aeads, aead)
+	}
+
+	if len(ring.signKeys) == 0 {
+		ring = nil
+	}
+	engine.cookieKeys = ring
+	return nil
+}
+
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// sign 使用 HMAC-SHA256 对 Cookie 名和值签名，签名中包含 name，防止把一个 Cookie 的值用于另一个 Cookie
+func sign(key []byte, name, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{'|'})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+func (r *keyRing) sign(name, value string) string {
+	encoding := base64.RawURLEncoding
+	return encoding.EncodeToString([]byte(value)) + "." + encoding.EncodeToString(sign(r.signKeys[0], name, value))
+}
+
+func (r *keyRing) verify(name, signed string) (string, error) {
+	i := strings.LastIndexByte(signed, '.')
+	if i < 0 {
+		return "", ErrInvalidCookie
+	}
+	value, err := base64.RawURLEncoding.DecodeString(signed[:i])
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signed[i+1:])
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	for _, key := range r.signKeys {
+		if hmac.Equal(mac, sign(key, name, string(value))) {
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// encrypt 使用 AES-GCM 加密，Cookie 名作为附加数据参与认证，结果为 base64(nonce + 密文)
+func (r *keyRing) encrypt(name, value string) (string, error) {
+	aead := r.aeads[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (r *keyRing) decrypt(name, encrypted string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	for _, aead := range r.aeads {
+		if len(data) < aead.NonceSize() {
+			return "", ErrInvalidCookie
+		}
+		nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+		if value, err := aead.Open(nil, nonce, ciphertext, []byte(name)); err == nil {
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// SetSignedCookie 设置使用 HMAC-SHA256 签名的 Cookie，值以明文保存但无法被客户端篡改，
+// 需要先通过 Engine.SetCookieKeys 配置密钥
+func (c *Context) SetSignedCookie(name string, value string, maxAge int, path, domain string, secure, httpOnly bool) error {
+	if c.engine.cookieKeys == nil {
+		return ErrNoCookieKeys
+	}
+	c.SetCookie(name, c.engine.cookieKeys.sign(name, value), maxAge, path, domain, secure, httpOnly)
+	return nil
+}
+
+// SignedCookie 读取并校验由 SetSignedCookie 设置的 Cookie，签名不正确时返回 ErrInvalidCookie
+func (c *Context) SignedCookie(name string) (string, error) {
+	if c.engine.cookieKeys == nil {
+		return "", ErrNoCookieKeys
+	}
+	val, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return c.engine.cookieKeys.verify(name, val)
+}
+
+// SetEncryptedCookie 设置使用 AES-GCM 加密的 Cookie，客户端既不能读取也不能篡改其中的值，
+// 需要先通过 Engine.SetCookieKeys 配置密钥
+func (c *Context) SetEncryptedCookie(name string, value string, maxAge int, path, domain string, secure, httpOnly bool) error {
+	if c.engine.cookieKeys == nil {
+		return ErrNoCookieKeys
+	}
+	encrypted, err := c.engine.cookieKeys.encrypt(name, value)
+	if err != nil {
+		return err
+	}
+	c.SetCookie(name, encrypted, maxAge, path, domain, secure, httpOnly)
+	return nil
+}
+
+// EncryptedCookie 读取并解密由 SetEncryptedCookie 设置的 Cookie，解密失败时返回 ErrInvalidCookie
+func (c *Context) EncryptedCookie(name string) (string, error) {
+	if c.engine.cookieKeys == nil {
+		return "", ErrNoCookieKeys
+	}
+	val, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return c.engine.cookieKeys.decrypt(name, val)
+}
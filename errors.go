@@ -0,0 +1,105 @@
+package gee
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// ErrorHandlerFunc 返回 error 的处理函数，通过 E 转换成 HandlerFunc 后注册路由，
+// 返回的 error 统一交给 Engine.ErrorHandler 处理
+type ErrorHandlerFunc func(*Context) error
+
+// E 将 ErrorHandlerFunc 转换成 HandlerFunc，如：engine.GET("/user/:id", gee.E(getUser))
+func E(fn ErrorHandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		if err := fn(c); err != nil {
+			_ = c.Error(err)
+			c.engine.handleError(c, err)
+		}
+	}
+}
+
+// HTTPError 携带状态码的错误，Message 会返回给客户端，Internal 只用于日志
+type HTTPError struct {
+	Code     int
+	Message  string
+	Internal error
+}
+
+// NewHTTPError 创建 HTTPError，message 为空时使用状态码描述
+func NewHTTPError(code int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	return &HTTPError{Code: code, Message: message}
+}
+
+// WithInternal 设置内部错误，返回新的 HTTPError
+func (e *HTTPError) WithInternal(err error) *HTTPError {
+	return &HTTPError{Code: e.Code, Message: e.Message, Internal: err}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Internal != nil {
+		return fmt.Sprintf("code=%d, message=%s, internal=%v", e.Code, e.Message, e.Internal)
+	}
+	return fmt.Sprintf("code=%d, message=%s", e.Code, e.Message)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Internal
+}
+
+func (engine *Engine) handleError(c *Context, err error) {
+	handler := engine.ErrorHandler
+	if handler == nil {
+		handler = DefaultErrorHandler
+	}
+	handler(c, err)
+}
+
+// DefaultErrorHandler 默认的错误处理函数：*Problem 直接渲染，*HTTPError 使用其状态码和 Message，
+// 校验失败和类型转换失败（见 Context.ValidationMessages）返回 400 和各字段的错误信息，
+// 其他错误记录日志后返回 500，不会把错误内容暴露给客户端。响应已经写入时只记录日志
+func DefaultErrorHandler(c *Context, err error) {
+	// 响应已经开始写入，无法再修改状态码，只记录日志
+	if c.Writer.Written() {
+		log.Printf("[%d] %s %s: %v\n", c.Writer.Status(), c.Method, c.Path, err)
+		c.Abort()
+		return
+	}
+
+	var problem *Problem
+	if errors.As(err, &problem) {
+		c.AbortWithProblem(problem)
+		return
+	}
+
+	// 校验或类型转换失败时返回 400 以及客户端语言的错误信息
+	if msgs := c.ValidationMessages(err); msgs != nil {
+		if c.engine.UseProblemDetails {
+			problem := NewProblem(http.StatusBadRequest, "request validation failed")
+			problem.Extensions = map[string]interface{}{"errors": msgs}
+			c.AbortWithProblem(problem)
+			return
+		}
+		c.Abort()
+		c.JSON(http.StatusBadRequest, H{"errors": msgs})
+		return
+	}
+
+	code, message := http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+	var he *HTTPError
+	if errors.As(err, &he) {
+		code, message = he.Code, he.Message
+		if he.Internal != nil {
+			log.Printf("[%d] %s %s: %v\n", code, c.Method, c.Path, he.Internal)
+		}
+	} else {
+		log.Printf("[%d] %s %s: %v\n", code, c.Method, c.Path, err)
+	}
+
+	c.Fail(code, message)
+}
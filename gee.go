@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/Knight-7/gee/rendering"
 )
 
 type HandlerFunc func(*Context)
@@ -20,11 +23,36 @@ type Engine struct {
 	*RouterGroup
 	router        *router
 	groups        []*RouterGroup
-	// HTML 渲染
-	htmlTemplates *template.Template
+	// HTML 渲染，默认为 LoadHTMLGlob、LoadHTMLFiles 创建的 rendering.HTMLProduction，
+	// 也可以设置为 rendering.Templates 或其他模板引擎
+	HTMLRender    rendering.HTMLRender
 	funcMap       template.FuncMap
 	// Context 池（减少 GC 带来的消耗）
 	pool          sync.Pool
+	// 可信代理，见 SetTrustedProxies
+	trustedCIDRs []*net.IPNet
+	// Context.RawBody 可缓存的请求体最大字节数，<= 0 表示不限制
+	MaxBodyCacheSize int64
+	// 文件上传：解析 multipart 表单时使用的最大内存，超出部分写入临时文件
+	MaxMultipartMemory int64
+	// 单个上传文件和整个上传请求体的最大字节数，<= 0 表示不限制
+	MaxUploadFileSize int64
+	MaxUploadSize     int64
+	// SafeSaveFile 保存文件的根目录
+	UploadRoot string
+	// 签名和加密 Cookie 的密钥环，见 SetCookieKeys
+	cookieKeys *keyRing
+	// 为 true 时，Fail、Recovery 以及 404/405 响应使用 RFC 7807 的 application/problem+json 格式
+	UseProblemDetails bool
+	// 为 true 时，路径存在但请求方法不匹配的请求返回 405 并设置 Allow 响应头，否则返回 404
+	HandleMethodNotAllowed bool
+	// 处理 ErrorHandlerFunc 返回的错误，为 nil 时使用 DefaultErrorHandler
+	ErrorHandler func(*Context, error)
+	// Context.SecureJSON 使用的前缀
+	SecureJSONPrefix string
+	// 为 true 时，LoadHTMLGlob、LoadHTMLFiles 加载的模板修改后会自动重新加载，用于开发环境。
+	// New 时默认为 IsDebugging()，即调试模式下开启、生产模式下关闭，需要在加载模板前修改
+	HTMLAutoReload bool
 }
 
 func (engine *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -39,12 +67,19 @@ func (engine *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	c.reset(w, r)
 	c.middlewares = middlewares
 	engine.router.handle(c)
+	c.Writer.WriteHeaderNow()
 
 	engine.pool.Put(c)
 }
 
 func New() *Engine {
-	engine := &Engine{router: newRouter()}
+	engine := &Engine{
+		router:             newRouter(),
+		MaxBodyCacheSize:   defaultMaxMemory,
+		MaxMultipartMemory: defaultMaxMemory,
+		SecureJSONPrefix:   "while(1);",
+		HTMLAutoReload:     IsDebugging(),
+	}
 	engine.RouterGroup = &RouterGroup{engine: engine}
 	engine.groups = []*RouterGroup{engine.RouterGroup}
 	engine.pool.New = func() interface{} {
@@ -63,11 +98,27 @@ func (engine *Engine) allocateContext() *Context {
 	return &Context{engine: engine}
 }
 
+// LoadHTMLGlob 加载 pattern 匹配的模板，模板出错时 panic。开启 HTMLAutoReload（调试模式下默认开启）时，
+// 模板修改后会自动重新加载，之后的模板错误以错误页面返回
 func (engine *Engine) LoadHTMLGlob(pattern string) {
-	engine.htmlTemplates = template.Must(template.New("").Funcs(engine.funcMap).ParseGlob(pattern))
+	tmpl := template.Must(template.New("").Funcs(engine.funcMap).ParseGlob(pattern))
+	if engine.HTMLAutoReload {
+		engine.HTMLRender = rendering.NewHTMLDebug(tmpl, pattern, nil, engine.funcMap)
+		return
+	}
+	engine.HTMLRender = rendering.HTMLProduction{Template: tmpl}
+}
+
+// LoadHTMLFiles 加载 files 中的模板，行为与 LoadHTMLGlob 相同
+func (engine *Engine) LoadHTMLFiles(files ...string) {
+	tmpl := template.Must(template.New("").Funcs(engine.funcMap).ParseFiles(files...))
+	if engine.HTMLAutoReload {
+		engine.HTMLRender = rendering.NewHTMLDebug(tmpl, "", files, engine.funcMap)
+		return
+	}
+	engine.HTMLRender = rendering.HTMLProduction{Template: tmpl}
 }
 
-// Run Graceful shutdown server
 func (engine *Engine) Run(addr string) {
 	assert1(addr != "", "Server address can't be null")
 
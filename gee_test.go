@@ -200,3 +200,52 @@ func TestEngineRun(t *testing.T) {
 	engine := Default()
 	engine.Run("")
 }
+
+func TestErrorHandler(t *testing.T) {
+	engine := New()
+	engine.GET("/ok", E(func(c *Context) error {
+		c.String(http.StatusOK, "ok")
+		return nil
+	}))
+	engine.GET("/http", E(func(c *Context) error {
+		return NewHTTPError(http.StatusNotFound, "user not found").WithInternal(fmt.Errorf("record not found"))
+	}))
+	engine.GET("/internal", E(func(c *Context) error {
+		return fmt.Errorf("db: connection refused")
+	}))
+	engine.GET("/problem", E(func(c *Context) error {
+		return fmt.Errorf("wrapped: %w", NewProblem(http.StatusConflict, "version mismatch"))
+	}))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/ok")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+
+	w = serve("/http")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, `"user not found"`, w.Body.String())
+
+	w = serve("/internal")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, `"Internal Server Error"`, w.Body.String())
+
+	w = serve("/problem")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Conflict","status":409,"detail":"version mismatch"}`, w.Body.String())
+
+	var handled error
+	engine.ErrorHandler = func(c *Context, err error) {
+		handled = err
+		c.AbortWithStatus(http.StatusTeapot)
+	}
+	w = serve("/internal")
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.EqualError(t, handled, "db: connection refused")
+}
@@ -4,5 +4,6 @@ go 1.15
 
 require (
 	github.com/stretchr/testify v1.6.1
+	github.com/vmihailenco/msgpack/v5 v5.3.5
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
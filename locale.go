@@ -0,0 +1,41 @@
+package gee
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/Knight-7/gee/binding"
+)
+
+// Locale 根据请求头 Accept-Language 选择已注册错误信息的语言（如 "zh-CN" 匹配 "zh"），
+// 按 q 值从高到低依次尝试，q 值相同时保持原有顺序，q=0 的语言会被忽略。
+// 没有匹配的语言时返回 binding.DefaultLocale
+func (c *Context) Locale() string {
+	specs := parseAccept(c.Request.Header.Get("Accept-Language"))
+	sort.SliceStable(specs, func(i, j int) bool {
+		return specs[i].q > specs[j].q
+	})
+	for _, spec := range specs {
+		if spec.q <= 0 || spec.mime == "*" {
+			break
+		}
+		if locale, ok := binding.MatchLocale(spec.mime); ok {
+			return locale
+		}
+	}
+	return binding.DefaultLocale
+}
+
+// ValidationMessages 将 Bind 返回的校验错误和类型转换错误（见 binding.TypeError）转换成客户端语言的错误信息，
+// 以字段名（json、form 标签中的名称）为键，如 {"age": "age must be at least 18"}。
+// err 不是这两类错误时返回 nil
+func (c *Context) ValidationMessages(err error) map[string]string {
+	var errs binding.ValidationErrors
+	if errors.As(err, &errs) {
+		return errs.Translate(c.Locale())
+	}
+	if fe := binding.TypeError(err); fe != nil {
+		return map[string]string{fe.Path: fe.Translate(c.Locale())}
+	}
+	return nil
+}
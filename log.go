@@ -12,5 +12,8 @@ func Logger() HandlerFunc {
 		c.Next()
 
 		log.Printf("[%d] %-7s %s in %v\n", c.Writer.Status(), c.Method, c.Request.RequestURI, time.Since(start))
+		for _, err := range c.Errors {
+			log.Printf("\tError: %v\n", err)
+		}
 	}
 }
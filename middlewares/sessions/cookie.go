@@ -0,0 +1,57 @@
+package sessions
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/gob"
+
+	"github.com/Knight-7/gee"
+)
+
+func init() {
+	// Flash 消息以 []interface{} 保存在会话中
+	gob.Register([]interface{}{})
+}
+
+type cookieStore struct {
+	options Options
+}
+
+// NewCookieStore 将会话数据以 gob 编码后保存在签名的 Cookie 中，
+// 需要先通过 Engine.SetCookieKeys 配置密钥。自定义类型需要先调用 gob.Register 注册
+func NewCookieStore(options Options) Store {
+	return &cookieStore{options: options.withDefaults()}
+}
+
+func (s *cookieStore) Load(c *gee.Context) (string, map[string]interface{}, error) {
+	val, err := c.SignedCookie(s.options.Name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(val)
+	if err != nil {
+		return "", nil, err
+	}
+	var values map[string]interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&values); err != nil {
+		return "", nil, err
+	}
+	return "", values, nil
+}
+
+func (s *cookieStore) Save(c *gee.Context, id string, values map[string]interface{}) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		return err
+	}
+
+	o := s.options
+	val := base64.RawURLEncoding.EncodeToString(buf.Bytes())
+	return c.SetSignedCookie(o.Name, val, o.MaxAge, o.Path, o.Domain, o.Secure, true)
+}
+
+// Delete 会话数据全部保存在 Cookie 中，服务端无需删除
+func (s *cookieStore) Delete(id string) error {
+	return nil
+}
@@ -0,0 +1,96 @@
+package sessions
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Knight-7/gee"
+)
+
+type memoryEntry struct {
+	values  map[string]interface{}
+	expires time.Time
+}
+
+type memoryStore struct {
+	options Options
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	lastGC  time.Time
+}
+
+// NewMemoryStore 将会话数据保存在内存中，Cookie 中只保存会话 ID，
+// 会话在 Options.MaxAge 秒内没有被保存时过期。只适用于单实例部署
+func NewMemoryStore(options Options) Store {
+	return &memoryStore{
+		options: options.withDefaults(),
+		entries: make(map[string]*memoryEntry),
+	}
+}
+
+func (s *memoryStore) Load(c *gee.Context) (string, map[string]interface{}, error) {
+	id, err := c.Cookie(s.options.Name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return "", nil, nil
+	}
+	if time.Now().After(entry.expires) {
+		delete(s.entries, id)
+		return "", nil, nil
+	}
+	return id, copyValues(entry.values), nil
+}
+
+func (s *memoryStore) Save(c *gee.Context, id string, values map[string]interface{}) error {
+	o := s.options
+	now := time.Now()
+
+	s.mu.Lock()
+	s.gc(now)
+	s.entries[id] = &memoryEntry{
+		values:  copyValues(values),
+		expires: now.Add(time.Duration(o.MaxAge) * time.Second),
+	}
+	s.mu.Unlock()
+
+	c.SetCookie(o.Name, id, o.MaxAge, o.Path, o.Domain, o.Secure, true)
+	return nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, id)
+	return nil
+}
+
+// gc 每分钟最多清理一次过期的会话，调用时需持有锁
+func (s *memoryStore) gc(now time.Time) {
+	if now.Sub(s.lastGC) < time.Minute {
+		return
+	}
+	s.lastGC = now
+
+	for id, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, id)
+		}
+	}
+}
+
+// copyValues 拷贝会话数据，避免并发请求共享同一个 map
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		m[k] = v
+	}
+	return m
+}
@@ -0,0 +1,164 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+
+	"github.com/Knight-7/gee"
+)
+
+const (
+	contextKey = "github.com/Knight-7/gee/middlewares/sessions"
+	flashKey   = "_flash"
+)
+
+// Store 会话的存储后端
+type Store interface {
+	// Load 从请求中加载会话，会话不存在时返回 nil 的 values
+	Load(c *gee.Context) (id string, values map[string]interface{}, err error)
+	// Save 保存会话，并在响应中写入对应的 Cookie
+	Save(c *gee.Context, id string, values map[string]interface{}) error
+	// Delete 删除服务端保存的会话数据，Regenerate 时会调用
+	Delete(id string) error
+}
+
+// Options 会话 Cookie 的配置
+type Options struct {
+	Name   string // Cookie 名，默认为 "gee_session"
+	Path   string // 默认为 "/"
+	Domain string
+	MaxAge int // 会话有效期（秒），默认为 7 天
+	Secure bool
+}
+
+func (o Options) withDefaults() Options {
+	if o.Name == "" {
+		o.Name = "gee_session"
+	}
+	if o.Path == "" {
+		o.Path = "/"
+	}
+	if o.MaxAge == 0 {
+		o.MaxAge = 7 * 24 * 60 * 60
+	}
+	return o
+}
+
+// Session 一次请求中的会话，通过 Get 获取
+type Session struct {
+	ID      string
+	Values  map[string]interface{}
+	c       *gee.Context
+	store   Store
+	loaded  bool
+	changed bool
+}
+
+// Middleware 会话中间件，会话在第一次调用 Get 时加载，被修改过的会话会在响应头写入前自动保存
+func Middleware(store Store) gee.HandlerFunc {
+	return func(c *gee.Context) {
+		s := &Session{c: c, store: store}
+		c.Set(contextKey, s)
+		c.Writer.Before(func() {
+			_ = s.saveIfChanged()
+		})
+
+		c.Next()
+	}
+}
+
+// Get 获取当前请求的会话，必须在使用了 Middleware 的路由中调用
+func Get(c *gee.Context) *Session {
+	s := c.MustGet(contextKey).(*Session)
+	s.load()
+	return s
+}
+
+func (s *Session) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+
+	id, values, err := s.store.Load(s.c)
+	// 会话无效（如签名错误、已过期）时，开始一个新的会话
+	if err != nil || values == nil {
+		id, values = "", make(map[string]interface{})
+	}
+	if id == "" {
+		id = newID()
+	}
+	s.ID = id
+	s.Values = values
+}
+
+func (s *Session) Get(key string) interface{} {
+	return s.Values[key]
+}
+
+func (s *Session) Set(key string, value interface{}) {
+	s.Values[key] = value
+	s.changed = true
+}
+
+func (s *Session) Delete(key string) {
+	delete(s.Values, key)
+	s.changed = true
+}
+
+// Clear 删除会话中的所有数据
+func (s *Session) Clear() {
+	s.Values = make(map[string]interface{})
+	s.changed = true
+}
+
+// Flash 添加一条只会被读取一次的消息
+func (s *Session) Flash(value interface{}) {
+	flashes, _ := s.Values[flashKey].([]interface{})
+	s.Set(flashKey, append(flashes, value))
+}
+
+// Flashes 返回并删除所有 Flash 消息
+func (s *Session) Flashes() []interface{} {
+	flashes, ok := s.Values[flashKey].([]interface{})
+	if ok {
+		s.Delete(flashKey)
+	}
+	return flashes
+}
+
+// Regenerate 更换会话 ID 并删除旧的会话数据，保留会话中的值，
+// 用于登录等权限变化的场景，防止会话固定攻击
+func (s *Session) Regenerate() error {
+	if err := s.store.Delete(s.ID); err != nil {
+		return err
+	}
+	s.ID = newID()
+	s.changed = true
+	return nil
+}
+
+// Save 立即保存会话，一般不需要手动调用
+func (s *Session) Save() error {
+	if err := s.store.Save(s.c, s.ID, s.Values); err != nil {
+		return err
+	}
+	s.changed = false
+	return nil
+}
+
+func (s *Session) saveIfChanged() error {
+	if !s.loaded || !s.changed {
+		return nil
+	}
+	return s.Save()
+}
+
+func newID() string {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
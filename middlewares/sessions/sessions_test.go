@@ -0,0 +1,105 @@
+package sessions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Knight-7/gee"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupEngine(t *testing.T, store Store) *gee.Engine {
+	engine := gee.New()
+	assert.Nil(t, engine.SetCookieKeys([]byte("0123456789abcdef")))
+	engine.Use(Middleware(store))
+	engine.GET("/login", func(c *gee.Context) {
+		s := Get(c)
+		assert.Nil(t, s.Regenerate())
+		s.Set("user", "knight")
+		s.Flash("welcome")
+		c.String(http.StatusOK, s.ID)
+	})
+	engine.GET("/me", func(c *gee.Context) {
+		s := Get(c)
+		flashes := s.Flashes()
+		user, _ := s.Get("user").(string)
+		if len(flashes) > 0 {
+			user += " " + flashes[0].(string)
+		}
+		c.String(http.StatusOK, user)
+	})
+	engine.GET("/logout", func(c *gee.Context) {
+		Get(c).Delete("user")
+	})
+	return engine
+}
+
+func serve(engine *gee.Engine, path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func testStore(t *testing.T, store Store) {
+	engine := setupEngine(t, store)
+
+	w := serve(engine, "/me", nil)
+	assert.Equal(t, "", w.Body.String())
+	assert.Empty(t, w.Result().Cookies())
+
+	w = serve(engine, "/login", nil)
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "gee_session", cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+
+	// Flash 消息只能读取一次
+	w = serve(engine, "/me", cookies)
+	assert.Equal(t, "knight welcome", w.Body.String())
+	if c := w.Result().Cookies(); len(c) > 0 {
+		cookies = c
+	}
+	w = serve(engine, "/me", cookies)
+	assert.Equal(t, "knight", w.Body.String())
+
+	// 没有写入响应体时，会话在中间件返回前保存
+	w = serve(engine, "/logout", cookies)
+	cookies = w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	w = serve(engine, "/me", cookies)
+	assert.Equal(t, "", w.Body.String())
+}
+
+func TestCookieStore(t *testing.T) {
+	testStore(t, NewCookieStore(Options{}))
+
+	// 被篡改的 Cookie 会被忽略
+	engine := setupEngine(t, NewCookieStore(Options{}))
+	w := serve(engine, "/me", []*http.Cookie{{Name: "gee_session", Value: "bad.cookie"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", w.Body.String())
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore(Options{}))
+
+	store := NewMemoryStore(Options{Name: "sid"})
+	engine := setupEngine(t, store)
+
+	w := serve(engine, "/login", nil)
+	first := w.Result().Cookies()
+	assert.Equal(t, "sid", first[0].Name)
+	assert.Equal(t, w.Body.String(), first[0].Value)
+
+	// Regenerate 后旧的会话 ID 失效
+	w = serve(engine, "/login", first)
+	second := w.Result().Cookies()
+	assert.NotEqual(t, first[0].Value, second[0].Value)
+	assert.Equal(t, "", serve(engine, "/me", first).Body.String())
+	assert.Equal(t, "knight welcome", serve(engine, "/me", second).Body.String())
+}
@@ -0,0 +1,40 @@
+package gee
+
+import "os"
+
+// EnvGeeMode 通过环境变量设置运行模式
+const EnvGeeMode = "GEE_MODE"
+
+const (
+	// DebugMode 调试模式：输出警告等调试信息
+	DebugMode = "debug"
+	// ReleaseMode 生产模式：不输出调试信息
+	ReleaseMode = "release"
+)
+
+var geeMode = DebugMode
+
+func init() {
+	if mode := os.Getenv(EnvGeeMode); mode != "" {
+		SetMode(mode)
+	}
+}
+
+// SetMode 设置运行模式
+func SetMode(mode string) {
+	switch mode {
+	case DebugMode, ReleaseMode:
+		geeMode = mode
+	default:
+		panic("gee mode unknown: " + mode + " (available mode: debug release)")
+	}
+}
+
+func Mode() string {
+	return geeMode
+}
+
+// IsDebugging 判断是否处于调试模式
+func IsDebugging() bool {
+	return geeMode == DebugMode
+}
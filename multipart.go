@@ -0,0 +1,112 @@
+package gee
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+var (
+	ErrFileTooLarge      = errors.New("upload file too large")
+	ErrInvalidUploadPath = errors.New("invalid upload path")
+)
+
+// MultipartPart 对 multipart.Part 的封装，读取文件内容时会检查 Engine.MaxUploadFileSize
+type MultipartPart struct {
+	*multipart.Part
+	limit int64
+	read  int64
+}
+
+func (p *MultipartPart) Read(b []byte) (int, error) {
+	if p.limit <= 0 {
+		return p.Part.Read(b)
+	}
+	return limitRead(p.Part, b, p.limit, &p.read, ErrFileTooLarge)
+}
+
+// limitedBody 限制请求体的总大小，超过 limit 时返回 ErrBodyTooLarge
+type limitedBody struct {
+	io.ReadCloser
+	limit int64
+	read  int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	return limitRead(b.ReadCloser, p, b.limit, &b.read, ErrBodyTooLarge)
+}
+
+// limitRead 从 r 中最多读取 limit 个字节，read 记录已读取的字节数，
+// 数据超过 limit 时只返回 limit 以内的部分和 tooLarge 错误
+func limitRead(r io.Reader, p []byte, limit int64, read *int64, tooLarge error) (int, error) {
+	remain := limit - *read
+	if int64(len(p)) > remain+1 {
+		p = p[:remain+1]
+	}
+
+	n, err := r.Read(p)
+	if int64(n) > remain {
+		*read = limit
+		return int(remain), tooLarge
+	}
+	*read += int64(n)
+	return n, err
+}
+
+// limitBody 当设置了 Engine.MaxUploadSize 时，给请求体加上总大小限制
+func (c *Context) limitBody() {
+	limit := c.engine.MaxUploadSize
+	if limit <= 0 || c.Request.Body == nil {
+		return
+	}
+	if _, ok := c.Request.Body.(*limitedBody); ok {
+		return
+	}
+	c.Request.Body = &limitedBody{ReadCloser: c.Request.Body, limit: limit}
+}
+
+// MultipartReader 返回流式读取 multipart/form-data 请求体的 Reader，
+// 不会把文件缓存到内存或磁盘中。请求体总大小受 Engine.MaxUploadSize 限制
+func (c *Context) MultipartReader() (*multipart.Reader, error) {
+	c.limitBody()
+	return c.Request.MultipartReader()
+}
+
+// StreamMultipart 依次对请求中的每个 part 调用 fn，part 在 fn 返回后会被关闭。
+// 文件大小超过 Engine.MaxUploadFileSize 或请求体超过 Engine.MaxUploadSize 时，
+// 会以 413 中止请求并返回 ErrFileTooLarge 或 ErrBodyTooLarge
+func (c *Context) StreamMultipart(fn func(part *MultipartPart) error) error {
+	reader, err := c.MultipartReader()
+	if err != nil {
+		return err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return c.multipartError(err)
+		}
+
+		p := &MultipartPart{Part: part}
+		if part.FileName() != "" {
+			p.limit = c.engine.MaxUploadFileSize
+		}
+		err = fn(p)
+		_ = part.Close()
+		if err != nil {
+			return c.multipartError(err)
+		}
+	}
+}
+
+// multipartError 超出大小限制时以 413 中止请求
+func (c *Context) multipartError(err error) error {
+	if errors.Is(err, ErrBodyTooLarge) || errors.Is(err, ErrFileTooLarge) {
+		c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package gee
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Knight-7/gee/rendering"
+)
+
+// acceptSpec Accept 请求头中的一项，如 "text/html;q=0.8"
+type acceptSpec struct {
+	mime string
+	q    float64
+}
+
+// parseAccept 解析 Accept 请求头，保留 q=0 的项，用于排除对应的类型
+func parseAccept(header string) []acceptSpec {
+	var specs []acceptSpec
+	for _, item := range strings.Split(header, ",") {
+		parts := strings.Split(item, ";")
+		mime := strings.ToLower(strings.TrimSpace(parts[0]))
+		if mime == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		specs = append(specs, acceptSpec{mime: mime, q: q})
+	}
+	return specs
+}
+
+// quality 返回 offer 的 q 值，取匹配 offer 的最具体的一项，
+// 如 "*/*, application/json;q=0" 中 application/json 的 q 值为 0
+func quality(specs []acceptSpec, offer string) float64 {
+	q, best := 0.0, 0
+	for _, spec := range specs {
+		if !matchMIME(spec.mime, offer) {
+			continue
+		}
+		if s := specificity(spec.mime); s > best {
+			q, best = spec.q, s
+		}
+	}
+	return q
+}
+
+func specificity(mime string) int {
+	switch {
+	case mime == "*/*":
+		return 1
+	case strings.HasSuffix(mime, "/*"):
+		return 2
+	}
+	return 3
+}
+
+// matchMIME 判断 offer 是否匹配 Accept 中的 accept，支持 "*/*" 和 "type/*"
+func matchMIME(accept, offer string) bool {
+	if accept == "*/*" || accept == offer {
+		return true
+	}
+	if strings.HasSuffix(accept, "/*") {
+		return strings.HasPrefix(offer, accept[:len(accept)-1])
+	}
+	return false
+}
+
+// NegotiateFormat 根据请求头 Accept 从 offered 中选择最合适的 MIME 类型，没有可接受的类型时返回 ""。
+// 没有 Accept 请求头时返回 offered[0]
+func (c *Context) NegotiateFormat(offered ...string) string {
+	assert1(len(offered) > 0, "You must provide at least one offer")
+
+	header := c.Request.Header.Get("Accept")
+	if header == "" {
+		return offered[0]
+	}
+
+	// 选择 q 值最高的类型，q 值相同时按 offered 的顺序
+	specs := parseAccept(header)
+	format, best := "", 0.0
+	for _, offer := range offered {
+		if q := quality(specs, strings.ToLower(offer)); q > best {
+			format, best = offer, q
+		}
+	}
+	return format
+}
+
+// Negotiate 通过内容协商选择 rendering 中注册的格式渲染 data，offered 为空时可以使用所有已注册的格式。
+// 没有可接受的格式时返回 406
+func (c *Context) Negotiate(code int, data interface{}, offered ...string) {
+	if len(offered) == 0 {
+		offered = rendering.MIMETypes()
+	}
+
+	mime := c.NegotiateFormat(offered...)
+	factory, ok := rendering.Lookup(mime)
+	if !ok {
+		c.AbortWithStatus(http.StatusNotAcceptable)
+		return
+	}
+
+	// Content-Type 使用 Render 自身的（包括 charset），媒体类型替换为协商出的类型，
+	// 这样 vnd.*+json 等类型可以复用已有的 Render
+	r := factory(data)
+	if c.Writer.Header().Get("Content-Type") == "" {
+		r.WriteContentType(c.Writer)
+		contentType, params := c.Writer.Header().Get("Content-Type"), ""
+		if i := strings.IndexByte(contentType, ';'); i >= 0 {
+			contentType, params = contentType[:i], contentType[i:]
+		}
+		if !strings.EqualFold(strings.TrimSpace(contentType), mime) {
+			c.SetHeader("Content-Type", mime+params)
+		}
+	}
+	c.Render(code, r)
+}
@@ -0,0 +1,78 @@
+package gee
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Knight-7/gee/rendering"
+)
+
+// Problem RFC 7807 定义的错误响应格式，Extensions 中的字段会和标准字段平铺在同一个 JSON 对象中
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// NewProblem 创建一个 Type 为 "about:blank"、Title 为状态码描述的 Problem
+func NewProblem(code int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: detail,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Title + ": " + p.Detail
+	}
+	return p.Title
+}
+
+func (p Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+	if p.Title != "" {
+		m["title"] = p.Title
+	}
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+// Problem 以 application/problem+json 渲染错误信息，code 为 0 时使用 p.Status
+func (c *Context) Problem(code int, p *Problem) {
+	if code == 0 {
+		code = p.Status
+	}
+	if code == 0 {
+		code = http.StatusInternalServerError
+	}
+	if p.Status == 0 {
+		p.Status = code
+	}
+	c.Render(code, rendering.Problem{Data: p})
+}
+
+// AbortWithProblem 中止请求，并以 p.Status 作为状态码渲染错误信息
+func (c *Context) AbortWithProblem(p *Problem) {
+	c.Abort()
+	c.Problem(p.Status, p)
+}
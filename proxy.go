@@ -0,0 +1,134 @@
+package gee
+
+import (
+	"net"
+	"strings"
+)
+
+// SetTrustedProxies 设置可信代理的 IP 或 CIDR 列表（如 "10.0.0.0/8"、"192.168.1.1"）。
+// 只有当请求的直接对端在该列表中时，ClientIP、Scheme、Host 才会读取
+// Forwarded、X-Forwarded-For、X-Real-IP 等由代理设置的请求头
+func (engine *Engine) SetTrustedProxies(proxies []string) error {
+	cidrs := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		cidr, err := parseCIDR(proxy)
+		if err != nil {
+			return err
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	engine.trustedCIDRs = cidrs
+	return nil
+}
+
+// isTrustedProxy 判断 ip 是否在可信代理列表中
+func (engine *Engine) isTrustedProxy(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range engine.trustedCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCIDR 解析 CIDR，单个 IP 会被转换成 /32（IPv4）或 /128（IPv6）
+func parseCIDR(proxy string) (*net.IPNet, error) {
+	if !strings.Contains(proxy, "/") {
+		ip := net.ParseIP(proxy)
+		if ip == nil {
+			return nil, &net.ParseError{Type: "IP address", Text: proxy}
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, cidr, err := net.ParseCIDR(proxy)
+	if err != nil {
+		return nil, err
+	}
+	return cidr, nil
+}
+
+// parseIP 解析 "ip"、"ip:port"、"[ipv6]:port" 形式的地址
+func parseIP(addr string) net.IP {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+	return net.ParseIP(addr)
+}
+
+// forwardedElement 对应 RFC 7239 Forwarded 请求头中的一个逗号分隔的元素
+type forwardedElement struct {
+	For   string
+	Proto string
+	Host  string
+}
+
+// parseForwarded 解析 RFC 7239 Forwarded 请求头，
+// 如：Forwarded: for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711"
+func parseForwarded(values []string) []forwardedElement {
+	var elements []forwardedElement
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			var element forwardedElement
+			for _, pair := range strings.Split(item, ";") {
+				i := strings.IndexByte(pair, '=')
+				if i < 0 {
+					continue
+				}
+				key := strings.ToLower(strings.TrimSpace(pair[:i]))
+				val := strings.Trim(strings.TrimSpace(pair[i+1:]), "\"")
+				switch key {
+				case "for":
+					element.For = val
+				case "proto":
+					element.Proto = strings.ToLower(val)
+				case "host":
+					element.Host = val
+				}
+			}
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// splitHeader 将 X-Forwarded-For 等逗号分隔的请求头拆分成列表
+func splitHeader(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// clientIPFromChain 从右往左遍历代理链，跳过可信代理，返回第一个不可信的地址；
+// 若整条链都是可信代理，则返回最左边的地址
+func (engine *Engine) clientIPFromChain(chain []string) (string, bool) {
+	var ip net.IP
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip = parseIP(chain[i])
+		if ip == nil {
+			// 链中存在无法解析的地址（如 "unknown"），无法继续确定客户端 IP
+			return "", false
+		}
+		if !engine.isTrustedProxy(ip) {
+			return ip.String(), true
+		}
+	}
+	if ip == nil {
+		return "", false
+	}
+	return ip.String(), true
+}
@@ -26,8 +26,16 @@ func Recovery() HandlerFunc {
 	return func(c *Context) {
 		defer func() {
 			if r := recover(); r != nil {
+				// http.ErrAbortHandler 用于中断连接，交给 net/http 处理
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
 				message := fmt.Sprintf("%s", r)
 				log.Printf("%s\n\n", trace(message))
+				if c.engine.UseProblemDetails {
+					c.AbortWithProblem(NewProblem(http.StatusInternalServerError, ""))
+					return
+				}
 				c.Fail(http.StatusInternalServerError, "Internal Server Error")
 			}
 		}()
@@ -0,0 +1,123 @@
+package rendering
+
+import (
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// CSV 逐行写出 CSV，Data 可以是 [][]string，也可以是结构体（或结构体指针）的切片。
+// 结构体切片的表头来自字段的 csv 标签，没有标签时使用字段名，标签为 "-" 的字段会被忽略
+type CSV struct {
+	Data interface{}
+}
+
+type csvField struct {
+	index int
+	field reflect.StructField
+}
+
+func (r CSV) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	writer := csv.NewWriter(w)
+	if rows, ok := r.Data.([][]string); ok {
+		for _, row := range rows {
+			if err := writeCSVRow(writer, row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(r.Data))
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return fmt.Errorf("csv: unsupported type %T", r.Data)
+	}
+	elemType := value.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("csv: unsupported type %T", r.Data)
+	}
+
+	fields, header := csvFields(elemType)
+	if err := writeCSVRow(writer, header); err != nil {
+		return err
+	}
+	for i := 0; i < value.Len(); i++ {
+		elem := reflect.Indirect(value.Index(i))
+		if !elem.IsValid() {
+			continue
+		}
+		row := make([]string, len(fields))
+		for j, f := range fields {
+			row[j] = formatCSVValue(elem.Field(f.index), f.field)
+		}
+		if err := writeCSVRow(writer, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r CSV) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, csvContentType)
+}
+
+// writeCSVRow 写入一行后立即刷新，不在内存中缓存整个 CSV
+func writeCSVRow(writer *csv.Writer, row []string) error {
+	if err := writer.Write(row); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvFields(t reflect.Type) ([]csvField, []string) {
+	var fields []csvField
+	var header []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("csv")
+		// 跳过不可导出的字段和标签为 "-" 的字段
+		if f.PkgPath != "" || tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		fields = append(fields, csvField{index: i, field: f})
+		header = append(header, tag)
+	}
+	return fields, header
+}
+
+func formatCSVValue(value reflect.Value, field reflect.StructField) string {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+
+	switch v := value.Interface().(type) {
+	case time.Time:
+		if format := field.Tag.Get("time_format"); format != "" {
+			return v.Format(format)
+		}
+		return v.Format(time.RFC3339)
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(text)
+	default:
+		return fmt.Sprint(v)
+	}
+}
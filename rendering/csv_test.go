@@ -0,0 +1,40 @@
+package rendering
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := CSV{Data: [][]string{{"id", "name"}, {"1", "knight, jr"}}}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "id,name\n1,\"knight, jr\"\n", w.Body.String())
+
+	type row struct {
+		ID       int       `csv:"id"`
+		Name     string    `csv:"name"`
+		Score    *float64  `csv:"score"`
+		Birthday time.Time `csv:"birthday" time_format:"2006-01-02"`
+		Password string    `csv:"-"`
+		Male     bool
+	}
+	score := 99.5
+	rows := []*row{
+		{ID: 1, Name: "knight", Score: &score, Birthday: time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC), Male: true},
+		nil,
+		{ID: 2, Name: "kobe"},
+	}
+
+	w = httptest.NewRecorder()
+	err = CSV{Data: rows}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "id,name,score,birthday,Male\n1,knight,99.5,2000-01-02,true\n2,kobe,,0001-01-01,false\n", w.Body.String())
+
+	err = CSV{Data: map[string]string{}}.Render(httptest.NewRecorder())
+	assert.NotNil(t, err)
+}
@@ -1,26 +1,64 @@
 package rendering
 
 import (
-	"html/template"
+	"bytes"
+	"fmt"
+	"io"
 	"net/http"
 )
 
+// Executor 模板执行器，html/template 和 text/template 的 *Template 都实现了该接口
+type Executor interface {
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+// HTMLRender 根据模板名和数据创建 Render，Engine 通过它渲染 Context.HTML，
+// 可以替换成其他模板引擎
+type HTMLRender interface {
+	Instance(name string, data interface{}) Render
+}
+
+// HTMLProduction 默认的 HTMLRender，所有模板在同一个模板集合中，按模板名执行
+type HTMLProduction struct {
+	Template Executor
+}
+
+func (r HTMLProduction) Instance(name string, data interface{}) Render {
+	return HTML{
+		Name:     name,
+		Data:     data,
+		Template: r.Template,
+	}
+}
+
 type HTML struct {
 	Name     string
 	Data     interface{}
-	Template *template.Template
+	Template Executor
+	// ContentType 为空时使用 text/html，使用 text/template 渲染纯文本时为 text/plain
+	ContentType string
 }
 
 func (r HTML) Render(w http.ResponseWriter) error {
 	r.WriteContentType(w)
 
-	err := r.Template.ExecuteTemplate(w, r.Name, r.Data)
-	if err != nil {
+	if r.Template == nil {
+		return fmt.Errorf("html template %q is not defined", r.Name)
+	}
+
+	// 先渲染到缓冲区中，模板执行出错时不会写出不完整的页面
+	var buf bytes.Buffer
+	if err := r.Template.ExecuteTemplate(&buf, r.Name, r.Data); err != nil {
 		return err
 	}
-	return nil
+	_, err := buf.WriteTo(w)
+	return err
 }
 
 func (r HTML) WriteContentType(w http.ResponseWriter) {
+	if r.ContentType != "" {
+		writeContentType(w, []string{r.ContentType})
+		return
+	}
 	writeContentType(w, htmlContentType)
 }
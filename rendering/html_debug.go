@@ -0,0 +1,148 @@
+package rendering
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// HTMLDebug 开启 Engine.HTMLAutoReload 时使用的 HTMLRender，每次渲染前检查模板文件是否有新增或修改（根据 mtime），
+// 有变化时重新解析。模板解析或执行出错时返回包含错误信息的 HTML 页面，而不是 panic
+type HTMLDebug struct {
+	Glob    string
+	Files   []string
+	FuncMap map[string]interface{}
+
+	mu     sync.Mutex
+	tmpl   *htmltemplate.Template
+	mtimes map[string]time.Time
+}
+
+// NewHTMLDebug 创建以已经解析好的 tmpl 为初始模板的 HTMLDebug，模板文件之后有变化时才重新解析
+func NewHTMLDebug(tmpl *htmltemplate.Template, glob string, files []string, funcMap map[string]interface{}) *HTMLDebug {
+	r := &HTMLDebug{Glob: glob, Files: files, FuncMap: funcMap}
+	if files, err := r.files(); err == nil {
+		if mtimes, err := modTimes(files); err == nil {
+			r.tmpl, r.mtimes = tmpl, mtimes
+		}
+	}
+	return r
+}
+
+func (r *HTMLDebug) Instance(name string, data interface{}) Render {
+	tmpl, err := r.load()
+	if err != nil {
+		return HTMLError{Err: err}
+	}
+	return debugHTML{HTML{Name: name, Data: data, Template: tmpl}}
+}
+
+// files 返回 Glob 匹配的文件或 Files
+func (r *HTMLDebug) files() ([]string, error) {
+	files := r.Files
+	if r.Glob != "" {
+		matches, err := filepath.Glob(r.Glob)
+		if err != nil {
+			return nil, err
+		}
+		files = matches
+	}
+	if len(files) == 0 {
+		return nil, errors.New("html/template: no files to parse")
+	}
+	return files, nil
+}
+
+func (r *HTMLDebug) load() (*htmltemplate.Template, error) {
+	files, err := r.files()
+	if err != nil {
+		return nil, err
+	}
+
+	mtimes, err := modTimes(files)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tmpl != nil && !changed(r.mtimes, mtimes) {
+		return r.tmpl, nil
+	}
+	tmpl, err := htmltemplate.New("").Funcs(r.FuncMap).ParseFiles(files...)
+	if err != nil {
+		return nil, err
+	}
+	r.tmpl, r.mtimes = tmpl, mtimes
+	return tmpl, nil
+}
+
+func modTimes(files []string) (map[string]time.Time, error) {
+	mtimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		mtimes[file] = info.ModTime()
+	}
+	return mtimes, nil
+}
+
+func changed(old, new map[string]time.Time) bool {
+	if len(old) != len(new) {
+		return true
+	}
+	for file, mtime := range new {
+		if oldTime, ok := old[file]; !ok || !oldTime.Equal(mtime) {
+			return true
+		}
+	}
+	return false
+}
+
+// debugHTML 模板执行出错时渲染错误页面
+type debugHTML struct {
+	HTML
+}
+
+func (r debugHTML) Render(w http.ResponseWriter) error {
+	if err := r.HTML.Render(w); err != nil {
+		return HTMLError{Err: err}.Render(w)
+	}
+	return nil
+}
+
+// HTMLError 以 500 返回模板错误的页面，只用于开发环境
+type HTMLError struct {
+	Err error
+}
+
+func (r HTMLError) Render(w http.ResponseWriter) error {
+	// 错误页面替换原本的响应，所以覆盖已经设置的 Content-Type
+	w.Header()["Content-Type"] = htmlContentType
+	w.WriteHeader(http.StatusInternalServerError)
+
+	_, err := fmt.Fprintf(w, `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Template Error</title></head>
+<body>
+<h1>Template Error</h1>
+<pre style="background:#fee;padding:1em;white-space:pre-wrap">%s</pre>
+<p>Fix the template and reload the page. This page is only shown when template auto-reload is enabled.</p>
+</body>
+</html>
+`, html.EscapeString(r.Err.Error()))
+	return err
+}
+
+func (r HTMLError) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, htmlContentType)
+}
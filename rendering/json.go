@@ -1,8 +1,13 @@
 package rendering
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"regexp"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 type JSON struct {
@@ -27,3 +32,155 @@ func (r JSON) Render(w http.ResponseWriter) error {
 func (r JSON) WriteContentType(w http.ResponseWriter) {
 	writeContentType(w, jsonContentType)
 }
+
+// StreamJSON 使用 json.Encoder 直接写入 w，省去 json.Marshal 返回结果时的一次完整拷贝，适合导出较大的数据。
+// 输出末尾带有换行。需要逐条输出大量记录时使用 NDJSON
+type StreamJSON struct {
+	Data interface{}
+}
+
+func (r StreamJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.Data)
+}
+
+func (r StreamJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// IndentedJSON 带缩进的 JSON，便于阅读和调试
+type IndentedJSON struct {
+	Data interface{}
+}
+
+func (r IndentedJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	data, err := json.MarshalIndent(r.Data, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (r IndentedJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// SecureJSON 数据为 JSON 数组时在前面加上 Prefix（如 "while(1);"），防止 JSON 劫持
+type SecureJSON struct {
+	Prefix string
+	Data   interface{}
+}
+
+func (r SecureJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	data, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(data, []byte("[")) {
+		if _, err = w.Write([]byte(r.Prefix)); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (r SecureJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// callbackRegexp JSONP 回调函数名只能是 JavaScript 标识符或以 "." 连接的标识符
+var callbackRegexp = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+// IsValidCallback 判断 JSONP 的回调函数名是否合法
+func IsValidCallback(callback string) bool {
+	return len(callback) <= 128 && callbackRegexp.MatchString(callback)
+}
+
+// JSONP 以 callback(data); 的形式返回 JSON，Callback 不合法时返回错误
+type JSONP struct {
+	Callback string
+	Data     interface{}
+}
+
+func (r JSONP) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	if !IsValidCallback(r.Callback) {
+		return fmt.Errorf("invalid JSONP callback %q", r.Callback)
+	}
+	data, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+
+	// 开头的注释用于防止 Rosetta Flash 攻击
+	var buf bytes.Buffer
+	buf.WriteString("/**/")
+	buf.WriteString(r.Callback)
+	buf.WriteByte('(')
+	buf.Write(data)
+	buf.WriteString(");")
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func (r JSONP) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonpContentType)
+}
+
+// AsciiJSON 将非 ASCII 字符转义为 \uXXXX
+type AsciiJSON struct {
+	Data interface{}
+}
+
+func (r AsciiJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	data, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, ch := range string(data) {
+		if ch < utf8.RuneSelf {
+			buf.WriteRune(ch)
+			continue
+		}
+		// 超出 BMP 的字符需要转义成 UTF-16 代理对
+		if r1, r2 := utf16.EncodeRune(ch); r1 != utf8.RuneError {
+			fmt.Fprintf(&buf, "\\u%04x\\u%04x", r1, r2)
+		} else {
+			fmt.Fprintf(&buf, "\\u%04x", ch)
+		}
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func (r AsciiJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// PureJSON 不转义 HTML 字符（<、>、&）的 JSON
+type PureJSON struct {
+	Data interface{}
+}
+
+func (r PureJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(r.Data)
+}
+
+func (r PureJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
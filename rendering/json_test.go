@@ -0,0 +1,80 @@
+package rendering
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := JSON{Data: map[string]interface{}{"html": "<b>"}}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"html":"\u003cb\u003e"}`, w.Body.String())
+}
+
+func TestStreamJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := StreamJSON{Data: map[string]interface{}{"html": "<b>"}}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"html\":\"\\u003cb\\u003e\"}\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.NotNil(t, StreamJSON{Data: make(chan int)}.Render(w))
+	assert.Equal(t, 0, w.Body.Len())
+}
+
+func TestIndentedJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := IndentedJSON{Data: map[string]interface{}{"foo": "bar"}}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "{\n    \"foo\": \"bar\"\n}", w.Body.String())
+}
+
+func TestSecureJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := SecureJSON{Prefix: "while(1);", Data: []string{"foo", "bar"}}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, `while(1);["foo","bar"]`, w.Body.String())
+
+	// 不是数组时不加前缀
+	w = httptest.NewRecorder()
+	err = SecureJSON{Prefix: "while(1);", Data: map[string]string{"foo": "bar"}}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"foo":"bar"}`, w.Body.String())
+}
+
+func TestJSONP(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := JSONP{Callback: "app.callback", Data: map[string]string{"foo": "bar"}}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/javascript; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `/**/app.callback({"foo":"bar"});`, w.Body.String())
+
+	for _, callback := range []string{"", "alert(1)", "1abc", "a..b", "a;b"} {
+		assert.False(t, IsValidCallback(callback), callback)
+		err = JSONP{Callback: callback, Data: nil}.Render(httptest.NewRecorder())
+		assert.NotNil(t, err)
+	}
+	assert.True(t, IsValidCallback("$_jsonp1"))
+}
+
+func TestAsciiJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := AsciiJSON{Data: map[string]string{"lang": "GO语言", "emoji": "😀"}}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"emoji":"\ud83d\ude00","lang":"GO\u8bed\u8a00"}`, w.Body.String())
+}
+
+func TestPureJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := PureJSON{Data: map[string]string{"html": "<b>&</b>"}}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"html\":\"<b>&</b>\"}\n", w.Body.String())
+}
@@ -0,0 +1,32 @@
+package rendering
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgPack 优先使用 msgpack 标签，没有 msgpack 标签时使用 json 标签
+type MsgPack struct {
+	Data interface{}
+}
+
+// Render 先编码到缓冲区，编码失败时不会写出不完整的响应
+func (r MsgPack) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	// 与 binding.MsgPack 保持一致，结构体只需要 json 标签就可以同时用于 JSON 和 MsgPack
+	encoder.SetCustomStructTag("json")
+	if err := encoder.Encode(r.Data); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (r MsgPack) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, msgpackContentType)
+}
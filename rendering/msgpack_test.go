@@ -0,0 +1,46 @@
+package rendering
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgPack(t *testing.T) {
+	data := struct {
+		Name string `json:"name"`
+		Age  int    `json:"age" msgpack:"user_age"`
+	}{Name: "knight", Age: 18}
+
+	w := httptest.NewRecorder()
+	err := MsgPack{Data: data}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+
+	var m map[string]interface{}
+	assert.Nil(t, msgpack.Unmarshal(w.Body.Bytes(), &m))
+	assert.Equal(t, map[string]interface{}{"name": "knight", "user_age": int8(18)}, m)
+}
+
+func TestMsgPack_JSONTag(t *testing.T) {
+	type user struct {
+		Name  string `json:"name"`
+		Email string `json:"email,omitempty"`
+		Age   int    `json:"age" msgpack:"user_age"`
+	}
+
+	w := httptest.NewRecorder()
+	assert.Nil(t, MsgPack{Data: user{Name: "knight", Age: 18}}.Render(w))
+
+	// 编码时字段名来自 json 标签，omitempty 同样生效
+	var m map[string]interface{}
+	assert.Nil(t, msgpack.Unmarshal(w.Body.Bytes(), &m))
+	assert.Equal(t, map[string]interface{}{"name": "knight", "user_age": int8(18)}, m)
+
+	// 编码失败时不写出任何内容
+	w = httptest.NewRecorder()
+	assert.NotNil(t, MsgPack{Data: map[string]interface{}{"name": "knight", "ch": make(chan int)}}.Render(w))
+	assert.Equal(t, 0, w.Body.Len())
+}
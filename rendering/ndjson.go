@@ -0,0 +1,47 @@
+package rendering
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const defaultFlushInterval = 100 * time.Millisecond
+
+// NDJSON 从 Records 中逐条读取记录，每条记录编码为一行 JSON 写入，直到 Records 被关闭。
+// 每隔 FlushInterval（默认 100ms）刷新一次缓冲区，使客户端可以边接收边处理
+type NDJSON struct {
+	Records       <-chan interface{}
+	FlushInterval time.Duration
+}
+
+func (r NDJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	interval := r.FlushInterval
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	flusher, _ := w.(http.Flusher)
+
+	encoder := json.NewEncoder(w)
+	lastFlush := time.Now()
+	for record := range r.Records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+		if flusher != nil && time.Since(lastFlush) >= interval {
+			flusher.Flush()
+			lastFlush = time.Now()
+		}
+	}
+
+	if flusher != nil {
+		flusher.Flush()
+	}
+	return nil
+}
+
+func (r NDJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, ndjsonContentType)
+}
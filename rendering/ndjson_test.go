@@ -0,0 +1,25 @@
+package rendering
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNDJSON(t *testing.T) {
+	records := make(chan interface{})
+	go func() {
+		defer close(records)
+		for i := 0; i < 3; i++ {
+			records <- map[string]int{"id": i}
+		}
+	}()
+
+	w := httptest.NewRecorder()
+	err := NDJSON{Records: records}.Render(w)
+	assert.Nil(t, err)
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "application/x-ndjson; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"id\":0}\n{\"id\":1}\n{\"id\":2}\n", w.Body.String())
+}
@@ -0,0 +1,30 @@
+package rendering
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Problem 按照 RFC 7807 以 application/problem+json 渲染错误信息
+type Problem struct {
+	Data interface{}
+}
+
+func (r Problem) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	data, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r Problem) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, problemContentType)
+}
@@ -13,7 +13,7 @@ type Redirect struct {
 
 func (r Redirect) Render(w http.ResponseWriter) error {
 	if r.Code < http.StatusMultipleChoices || r.Code > http.StatusPermanentRedirect {
-		panic(fmt.Sprintf("Can not redirect with code %d", r.Code))
+		return fmt.Errorf("Can not redirect with code %d", r.Code)
 	}
 	http.Redirect(w, r.Request, r.Location, r.Code)
 	return nil
@@ -2,14 +2,21 @@ package rendering
 
 import (
 	"net/http"
+	"strings"
+	"sync"
 )
 
 var (
-	jsonContentType = []string{"application/json; charset=utf-8"}
-	textContentType = []string{"text/plain; charset=utf-8"}
-	xmlContentType  = []string{"application/xml; charset=utf-8"}
-	yamlContentType = []string{"application/x-yaml; charset=utf-8"}
-	htmlContentType = []string{"text/html; charset=utf-8"}
+	jsonContentType    = []string{"application/json; charset=utf-8"}
+	jsonpContentType   = []string{"application/javascript; charset=utf-8"}
+	ndjsonContentType  = []string{"application/x-ndjson; charset=utf-8"}
+	msgpackContentType = []string{"application/msgpack"}
+	csvContentType     = []string{"text/csv; charset=utf-8"}
+	textContentType    = []string{"text/plain; charset=utf-8"}
+	xmlContentType     = []string{"application/xml; charset=utf-8"}
+	yamlContentType    = []string{"application/x-yaml; charset=utf-8"}
+	htmlContentType    = []string{"text/html; charset=utf-8"}
+	problemContentType = []string{"application/problem+json; charset=utf-8"}
 )
 
 type Render interface {
@@ -17,6 +24,79 @@ type Render interface {
 	WriteContentType(http.ResponseWriter)
 }
 
+// Factory 根据数据创建 Render
+type Factory func(data interface{}) Render
+
+// renderers MIME 类型到 Factory 的注册表，用于内容协商，mimeTypes 记录注册顺序
+var (
+	renderersMu sync.RWMutex
+	renderers   = make(map[string]Factory)
+	mimeTypes   []string
+)
+
+func init() {
+	Register("application/json", func(data interface{}) Render { return JSON{Data: data} })
+	Register("application/xml", func(data interface{}) Render { return XML{Data: data} })
+	Register("text/xml", func(data interface{}) Render { return XML{Data: data} })
+	Register("application/x-yaml", func(data interface{}) Render { return YAML{Data: data} })
+	Register("application/msgpack", func(data interface{}) Render { return MsgPack{Data: data} })
+	Register("application/x-msgpack", func(data interface{}) Render { return MsgPack{Data: data} })
+	Register("text/plain", func(data interface{}) Render { return String{Format: "%v", Value: []interface{}{data}} })
+}
+
+// Register 注册 MIME 类型对应的 Factory，已存在时会覆盖，
+// 可用于支持 CBOR、MessagePack 或 application/vnd.*+json 等格式
+func Register(mime string, factory Factory) {
+	if factory == nil {
+		panic("rendering: Register factory is nil")
+	}
+	mime = strings.ToLower(strings.TrimSpace(mime))
+
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	if _, ok := renderers[mime]; !ok {
+		mimeTypes = append(mimeTypes, mime)
+	}
+	renderers[mime] = factory
+}
+
+// Unregister 移除 MIME 类型的注册，主要用于测试中恢复注册表
+func Unregister(mime string) {
+	mime = strings.ToLower(strings.TrimSpace(mime))
+
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	if _, ok := renderers[mime]; !ok {
+		return
+	}
+	delete(renderers, mime)
+	for i, t := range mimeTypes {
+		if t == mime {
+			mimeTypes = append(mimeTypes[:i:i], mimeTypes[i+1:]...)
+			break
+		}
+	}
+}
+
+// Lookup 查找 MIME 类型对应的 Factory
+func Lookup(mime string) (Factory, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+
+	factory, ok := renderers[strings.ToLower(mime)]
+	return factory, ok
+}
+
+// MIMETypes 按注册顺序返回所有已注册的 MIME 类型
+func MIMETypes() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+
+	types := make([]string, len(mimeTypes))
+	copy(types, mimeTypes)
+	return types
+}
+
 func writeContentType(w http.ResponseWriter, val []string) {
 	header := w.Header()
 	if v := header["Content-Type"]; len(v) == 0 {
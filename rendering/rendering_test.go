@@ -0,0 +1,24 @@
+package rendering
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	types := MIMETypes()
+	Register("Application/CBOR", func(data interface{}) Render { return JSON{Data: data} })
+	_, ok := Lookup("application/cbor")
+	assert.True(t, ok)
+	assert.Equal(t, append(types, "application/cbor"), MIMETypes())
+
+	Unregister("application/cbor")
+	_, ok = Lookup("application/cbor")
+	assert.False(t, ok)
+	assert.Equal(t, types, MIMETypes())
+
+	assert.Panics(t, func() {
+		Register("application/cbor", nil)
+	})
+}
@@ -0,0 +1,146 @@
+package rendering
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"path/filepath"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+// Templates 每个页面使用一个独立的模板集合，由布局、局部模板和页面模板组合而成，
+// 因此不同目录下的同名页面、以及各页面中同名的 {{define}} 块不会冲突
+type Templates struct {
+	// Reload 为 true 时，渲染前检查模板文件的修改时间并重新解析，出错时返回错误页面，用于开发环境
+	Reload bool
+
+	contentType string
+	parseFiles  func(files ...string) (Executor, string, error)
+	mu          sync.RWMutex
+	sets        map[string]*templateSet
+}
+
+type templateSet struct {
+	executor Executor
+	root     string // 执行的模板名，即第一个文件的文件名
+	files    []string
+	mtimes   map[string]time.Time
+}
+
+// NewHTMLTemplates 使用 html/template 解析模板
+func NewHTMLTemplates(funcMap map[string]interface{}) *Templates {
+	return &Templates{
+		parseFiles: func(files ...string) (Executor, string, error) {
+			tmpl, err := htmltemplate.New(filepath.Base(files[0])).Funcs(funcMap).ParseFiles(files...)
+			if err != nil {
+				return nil, "", err
+			}
+			return tmpl, tmpl.Name(), nil
+		},
+		sets: make(map[string]*templateSet),
+	}
+}
+
+// NewTextTemplates 使用 text/template 解析模板，以 text/plain 渲染，适用于纯文本邮件等场景
+func NewTextTemplates(funcMap map[string]interface{}) *Templates {
+	return &Templates{
+		contentType: textContentType[0],
+		parseFiles: func(files ...string) (Executor, string, error) {
+			tmpl, err := texttemplate.New(filepath.Base(files[0])).Funcs(funcMap).ParseFiles(files...)
+			if err != nil {
+				return nil, "", err
+			}
+			return tmpl, tmpl.Name(), nil
+		},
+		sets: make(map[string]*templateSet),
+	}
+}
+
+// AddFromFiles 将 files 解析成名为 name 的模板集合，渲染时执行第一个文件，
+// 所以第一个文件一般是布局文件，其余文件通过 {{define}} 定义布局中引用的块
+func (t *Templates) AddFromFiles(name string, files ...string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("template %q: no files", name)
+	}
+	set, err := t.parse(files)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.sets[name] = set
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *Templates) parse(files []string) (*templateSet, error) {
+	mtimes, err := modTimes(files)
+	if err != nil {
+		return nil, err
+	}
+	executor, root, err := t.parseFiles(files...)
+	if err != nil {
+		return nil, err
+	}
+	return &templateSet{executor: executor, root: root, files: files, mtimes: mtimes}, nil
+}
+
+// AddPages 为每个页面创建 layout + partials + 页面 组成的模板集合，
+// 页面名为页面文件的路径（使用 "/" 分隔），如 "views/admin/index.html"
+func (t *Templates) AddPages(layout string, partials []string, pages ...string) error {
+	for _, page := range pages {
+		files := make([]string, 0, len(partials)+2)
+		files = append(files, layout)
+		files = append(files, partials...)
+		files = append(files, page)
+		if err := t.AddFromFiles(filepath.ToSlash(page), files...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Templates) Instance(name string, data interface{}) Render {
+	t.mu.RLock()
+	set, ok := t.sets[name]
+	t.mu.RUnlock()
+
+	r := HTML{Name: name, Data: data, ContentType: t.contentType}
+	if !ok {
+		return r
+	}
+	if t.Reload {
+		var err error
+		if set, err = t.reload(name, set); err != nil {
+			return HTMLError{Err: err}
+		}
+	}
+
+	r.Name = set.root
+	r.Template = set.executor
+	if t.Reload {
+		return debugHTML{r}
+	}
+	return r
+}
+
+// reload 模板文件有修改时重新解析
+func (t *Templates) reload(name string, set *templateSet) (*templateSet, error) {
+	mtimes, err := modTimes(set.files)
+	if err != nil {
+		return nil, err
+	}
+	if !changed(set.mtimes, mtimes) {
+		return set, nil
+	}
+
+	newSet, err := t.parse(set.files)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	t.sets[name] = newSet
+	t.mu.Unlock()
+	return newSet, nil
+}
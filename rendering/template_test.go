@@ -0,0 +1,125 @@
+package rendering
+
+import (
+	htmltemplate "html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func render(r Render) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	if err := r.Render(w); err != nil {
+		w.Code = http.StatusInternalServerError
+	}
+	return w
+}
+
+func TestTemplates(t *testing.T) {
+	templates := NewHTMLTemplates(nil)
+	partials, _ := filepath.Glob("testdata/templates/partials/*.html")
+	err := templates.AddPages("testdata/templates/layouts/base.html", partials,
+		"testdata/templates/admin/index.html", "testdata/templates/user/index.html")
+	assert.Nil(t, err)
+
+	data := map[string]interface{}{"user": "knight", "body": "<b>"}
+	w := render(templates.Instance("testdata/templates/admin/index.html", data))
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<title>admin</title>\n<nav>knight</nav>\n<p>admin &lt;b&gt;</p>\n", w.Body.String())
+
+	// 同名页面和同名的 define 块不会冲突
+	w = render(templates.Instance("testdata/templates/user/index.html", data))
+	assert.Equal(t, "<title>gee</title>\n<nav>knight</nav>\n<p>user &lt;b&gt;</p>\n", w.Body.String())
+
+	assert.Equal(t, http.StatusInternalServerError, render(templates.Instance("missing", data)).Code)
+
+	emails := NewTextTemplates(nil)
+	assert.Nil(t, emails.AddFromFiles("welcome", "testdata/templates/email/welcome.txt"))
+	w = render(emails.Instance("welcome", map[string]interface{}{"user": "knight"}))
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Hello knight, welcome to <gee>!\n", w.Body.String())
+}
+
+// writeTemplate 写入模板并修改 mtime，避免文件系统时间精度导致修改检测不到
+func writeTemplate(t *testing.T, file, content string, mtime time.Time) {
+	assert.Nil(t, ioutil.WriteFile(file, []byte(content), 0644))
+	assert.Nil(t, os.Chtimes(file, mtime, mtime))
+}
+
+func TestTemplates_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gee-templates")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "page.html")
+	now := time.Now()
+	writeTemplate(t, file, "<p>{{.}}</p>", now)
+
+	templates := NewHTMLTemplates(nil)
+	templates.Reload = true
+	assert.Nil(t, templates.AddFromFiles("page", file))
+	assert.Equal(t, "<p>v1</p>", render(templates.Instance("page", "v1")).Body.String())
+
+	writeTemplate(t, file, "<div>{{.}}</div>", now.Add(time.Second))
+	assert.Equal(t, "<div>v2</div>", render(templates.Instance("page", "v2")).Body.String())
+
+	// 语法错误返回错误页面
+	writeTemplate(t, file, "<div>{{.</div>", now.Add(2*time.Second))
+	w := render(templates.Instance("page", "v3"))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "Template Error")
+
+	// 修复后恢复正常
+	writeTemplate(t, file, "<div>{{.}}</div>", now.Add(3*time.Second))
+	assert.Equal(t, "<div>v4</div>", render(templates.Instance("page", "v4")).Body.String())
+}
+
+func TestHTMLDebug(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gee-templates")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	writeTemplate(t, filepath.Join(dir, "a.html"), "a:{{.}}", now)
+
+	r := &HTMLDebug{Glob: filepath.Join(dir, "*.html")}
+	assert.Equal(t, "a:x", render(r.Instance("a.html", "x")).Body.String())
+
+	// 新增的文件会被加载
+	writeTemplate(t, filepath.Join(dir, "b.html"), "b:{{.}}", now)
+	assert.Equal(t, "b:y", render(r.Instance("b.html", "y")).Body.String())
+
+	// 模板执行出错时返回错误页面，错误信息会被转义
+	writeTemplate(t, filepath.Join(dir, "c.html"), "{{template \"<missing>\"}}", now)
+	w := render(r.Instance("c.html", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "&lt;missing&gt;")
+
+	writeTemplate(t, filepath.Join(dir, "c.html"), "{{if}}", now.Add(time.Second))
+	assert.Equal(t, http.StatusInternalServerError, render(r.Instance("a.html", "x")).Code)
+}
+
+func TestNewHTMLDebug(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gee-templates")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	file := filepath.Join(dir, "a.html")
+	writeTemplate(t, file, "disk:{{.}}", now)
+
+	// 文件没有变化时直接使用传入的模板，不会重新解析
+	tmpl := htmltemplate.Must(htmltemplate.New("").Parse(`{{define "a.html"}}seeded:{{.}}{{end}}`))
+	r := NewHTMLDebug(tmpl, filepath.Join(dir, "*.html"), nil, nil)
+	assert.Equal(t, "seeded:x", render(r.Instance("a.html", "x")).Body.String())
+
+	writeTemplate(t, file, "disk:{{.}}", now.Add(time.Second))
+	assert.Equal(t, "disk:x", render(r.Instance("a.html", "x")).Body.String())
+}
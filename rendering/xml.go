@@ -12,12 +12,12 @@ type XML struct {
 func (r XML) Render(w http.ResponseWriter) error {
 	r.WriteContentType(w)
 
-	encoder := xml.NewEncoder(w)
-	if err := encoder.Encode(r.Data); err != nil {
+	data, err := xml.Marshal(r.Data)
+	if err != nil {
 		return err
 	}
-
-	return nil
+	_, err = w.Write(data)
+	return err
 }
 
 func (r XML) WriteContentType(w http.ResponseWriter) {
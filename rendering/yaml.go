@@ -1,6 +1,7 @@
 package rendering
 
 import (
+	"bytes"
 	"gopkg.in/yaml.v3"
 	"net/http"
 )
@@ -9,21 +10,42 @@ type YAML struct {
 	Data interface{}
 }
 
+// Render 先编码到缓冲区，编码失败时不会写出不完整的响应
 func (r YAML) Render(w http.ResponseWriter) error {
 	r.WriteContentType(w)
 
-	data, err := yaml.Marshal(r.Data)
-	if err != nil {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	if err := encoder.Encode(r.Data); err != nil {
 		return err
 	}
-	_, err = w.Write(data)
-	if err != nil {
+	if err := encoder.Close(); err != nil {
 		return err
 	}
-
-	return nil
+	_, err := w.Write(buf.Bytes())
+	return err
 }
 
 func (r YAML) WriteContentType(w http.ResponseWriter) {
 	writeContentType(w, yamlContentType)
 }
+
+// StreamYAML 边编码边写入 w，不会把整个 YAML 缓存在内存中，适合导出较大的数据。
+// 编码失败时可能已经写出了部分内容，此时无法再返回 500，Context 会中断连接（见 Context.Render）
+type StreamYAML struct {
+	Data interface{}
+}
+
+func (r StreamYAML) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	encoder := yaml.NewEncoder(w)
+	if err := encoder.Encode(r.Data); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func (r StreamYAML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, yamlContentType)
+}
@@ -0,0 +1,49 @@
+package rendering
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingYAML struct{}
+
+func (failingYAML) MarshalYAML() (interface{}, error) {
+	return nil, errors.New("boom")
+}
+
+func TestYAML(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := YAML{Data: map[string]interface{}{"foo": "bar", "list": []int{1, 2}}}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/x-yaml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "foo: bar\nlist:\n  - 1\n  - 2\n", w.Body.String())
+
+	// 编码失败时不写出任何内容
+	w = httptest.NewRecorder()
+	err = YAML{Data: map[string]interface{}{"a": "ok", "z": failingYAML{}}}.Render(w)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, w.Body.Len())
+}
+
+func TestStreamYAML(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := StreamYAML{Data: map[string]interface{}{"foo": "bar", "list": []int{1, 2}}}.Render(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/x-yaml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "foo: bar\nlist:\n  - 1\n  - 2\n", w.Body.String())
+
+	// 编码过程中直接写入，失败时已经写出了部分内容
+	data := make(map[string]interface{})
+	for i := 0; i < 1000; i++ {
+		data[fmt.Sprintf("key%04d", i)] = "value"
+	}
+	data["zzz"] = failingYAML{}
+	w = httptest.NewRecorder()
+	assert.NotNil(t, StreamYAML{Data: data}.Render(w))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "key0000: value\n"))
+}
@@ -2,29 +2,51 @@ package gee
 
 import (
 	"bufio"
+	"log"
 	"net"
 	"net/http"
 )
 
-// 自定义一个 ResponseWriter 接口，来保存和获取 status 状态
+const noWritten = -1
+
+// 自定义一个 ResponseWriter 接口，来保存和获取 status 状态。
+// 底层的 http.ResponseWriter 不支持 Flush、Hijack、Push 时不会 panic：
+// Flush 什么都不做，Hijack、Push 和 FlushError 返回 http.ErrNotSupported。
+// 需要感知客户端断开连接时，使用 Context.Done()（即请求的 context）代替 CloseNotify
 type ResponseWriter interface {
 	http.ResponseWriter
 	http.Flusher
 	http.Hijacker
-	http.CloseNotifier
+	http.Pusher
 
 	Status() int
+	// Size 返回已写入响应体的字节数，响应头还未写入时返回 -1
+	Size() int
+	// Written 判断响应头是否已经写入
+	Written() bool
+	// WriteHeaderNow 立即写入响应头
+	WriteHeaderNow()
+	// Before 注册在响应头写入前执行的函数，可以用来在最后修改响应头
+	Before(func())
+	// FlushError 与 Flush 相同，但底层不支持时返回错误
+	FlushError() error
+	// Unwrap 返回底层的 http.ResponseWriter，用于兼容 http.ResponseController
+	Unwrap() http.ResponseWriter
 }
 
+// response 会延迟写入响应头，直到第一次写入响应体、调用 WriteHeaderNow 或请求处理结束
 type response struct {
 	http.ResponseWriter
-	status int
+	status      int
+	size        int
+	beforeFuncs []func()
 }
 
 func newResponse(writer http.ResponseWriter) *response {
 	return &response{
 		ResponseWriter: writer,
 		status:         http.StatusOK,
+		size:           noWritten,
 	}
 }
 
@@ -32,20 +54,90 @@ func (w *response) Status() int {
 	return w.status
 }
 
-// 覆盖 WriteHeader 方法，这样其他地方调用时会调用此方法，并将 status 保存到其中
+func (w *response) Size() int {
+	return w.size
+}
+
+func (w *response) Written() bool {
+	return w.size != noWritten
+}
+
+func (w *response) Before(fn func()) {
+	w.beforeFuncs = append(w.beforeFuncs, fn)
+}
+
+// 覆盖 WriteHeader 方法，这样其他地方调用时会调用此方法，并将 status 保存到其中；
+// 响应头已经写入后再次调用会被忽略，调试模式下会输出警告
 func (w *response) WriteHeader(code int) {
-	w.status = code
-	w.ResponseWriter.WriteHeader(code)
+	if code > 0 && w.status != code {
+		if w.Written() {
+			if IsDebugging() {
+				log.Printf("[WARNING] Headers were already written. Wanted to override status code %d with %d\n", w.status, code)
+			}
+			return
+		}
+		w.status = code
+	}
+}
+
+func (w *response) WriteHeaderNow() {
+	if w.Written() {
+		return
+	}
+
+	// 先标记为已写入，避免 before 函数中写入响应时重复执行
+	w.size = 0
+	for _, fn := range w.beforeFuncs {
+		fn()
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *response) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
 }
 
 func (w *response) Flush() {
-	w.ResponseWriter.(http.Flusher).Flush()
+	_ = w.FlushError()
+}
+
+func (w *response) FlushError() error {
+	w.WriteHeaderNow()
+	switch f := w.ResponseWriter.(type) {
+	case interface{ FlushError() error }:
+		return f.FlushError()
+	case http.Flusher:
+		f.Flush()
+		return nil
+	default:
+		return http.ErrNotSupported
+	}
 }
 
 func (w *response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return w.ResponseWriter.(http.Hijacker).Hijack()
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	// 连接被接管后不再由 response 写入响应头
+	if w.size < 0 {
+		w.size = 0
+	}
+	return hijacker.Hijack()
+}
+
+// Push 使用 HTTP/2 Server Push 推送资源，HTTP/1.x 下返回 http.ErrNotSupported
+func (w *response) Push(target string, opts *http.PushOptions) error {
+	pusher, ok := w.ResponseWriter.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+	return pusher.Push(target, opts)
 }
 
-func (w *response) CloseNotify() <-chan bool {
-	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+func (w *response) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
@@ -0,0 +1,152 @@
+package gee
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseWriter(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	w := newResponse(recorder)
+	w.Before(func() {
+		w.Header().Set("X-Size", "before")
+	})
+
+	assert.False(t, w.Written())
+	assert.Equal(t, -1, w.Size())
+
+	// 写入响应头前可以多次修改状态码
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusAccepted)
+	assert.False(t, w.Written())
+
+	n, err := w.Write([]byte("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, 5, n)
+	_, _ = w.Write([]byte(" world"))
+	assert.True(t, w.Written())
+	assert.Equal(t, 11, w.Size())
+
+	// 响应头写入后再修改状态码会被忽略
+	w.WriteHeader(http.StatusInternalServerError)
+	assert.Equal(t, http.StatusAccepted, w.Status())
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Equal(t, "before", recorder.Header().Get("X-Size"))
+	assert.Equal(t, "hello world", recorder.Body.String())
+}
+
+func TestResponseWriter_WriteHeaderNow(t *testing.T) {
+	engine := New()
+	engine.GET("/created", func(c *Context) {
+		c.Writer.Before(func() {
+			c.SetHeader("X-Status", http.StatusText(c.Writer.Status()))
+		})
+		c.Status(http.StatusCreated)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/created", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "Created", w.Header().Get("X-Status"))
+}
+
+func TestBuffered(t *testing.T) {
+	etag := func(c *Context) {
+		c.Next()
+		bw := c.Writer.(*BufferedWriter)
+		if !bw.Streaming() {
+			c.SetHeader("ETag", strconv.Quote(strconv.Itoa(len(bw.Body()))))
+			body := strings.ToUpper(string(bw.Body()))
+			bw.Reset()
+			_, _ = bw.Write([]byte(body))
+		}
+	}
+
+	engine := Default()
+	v1 := engine.Group("/v1")
+	v1.Use(Buffered(8), etag)
+	v1.GET("/small", func(c *Context) {
+		c.String(http.StatusCreated, "hello")
+	})
+	v1.GET("/large", func(c *Context) {
+		c.String(http.StatusOK, "hello world")
+	})
+	v1.GET("/panic", func(c *Context) {
+		c.SetHeader("X-Request-Token", "secret")
+		c.String(http.StatusOK, "hello")
+		panic("boom")
+	})
+	engine.Handle(http.MethodGet, "/render-error", Buffered(0), func(c *Context) {
+		c.String(http.StatusOK, "partial")
+		c.renderError(errors.New("boom"))
+	})
+	engine.Handle(http.MethodGet, "/route", Buffered(0), etag, func(c *Context) {
+		c.String(http.StatusOK, "route")
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/v1/small")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+	assert.Equal(t, "5", w.Header().Get("Content-Length"))
+	assert.Equal(t, "HELLO", w.Body.String())
+
+	// 超过阈值后直接写入，中间件无法再改写响应
+	w = serve("/v1/large")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", w.Header().Get("ETag"))
+	assert.Equal(t, "hello world", w.Body.String())
+
+	// panic 时丢弃缓存的响应
+	w = serve("/v1/panic")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, `"Internal Server Error"`, w.Body.String())
+	assert.Equal(t, "", w.Header().Get("X-Request-Token"))
+
+	// 渲染失败时缓存中的部分响应还没有写出，可以改为返回 500
+	w = serve("/render-error")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, `"Internal Server Error"`, w.Body.String())
+
+	w = serve("/route")
+	assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+	assert.Equal(t, "ROUTE", w.Body.String())
+}
+
+// basicWriter 只实现了 http.ResponseWriter
+type basicWriter struct {
+	http.ResponseWriter
+}
+
+func TestResponseWriter_OptionalInterfaces(t *testing.T) {
+	w := newResponse(basicWriter{httptest.NewRecorder()})
+	assert.NotPanics(t, w.Flush)
+	assert.Equal(t, http.ErrNotSupported, w.FlushError())
+	_, _, err := w.Hijack()
+	assert.Equal(t, http.ErrNotSupported, err)
+	assert.Equal(t, http.ErrNotSupported, w.Push("/app.js", nil))
+
+	recorder := httptest.NewRecorder()
+	w = newResponse(recorder)
+	_, _ = w.Write([]byte("hello"))
+	// 不依赖 http.ResponseController（Go 1.20），直接通过 http.Flusher 和 Unwrap 检查
+	flusher, ok := interface{}(w).(http.Flusher)
+	assert.True(t, ok)
+	flusher.Flush()
+	assert.True(t, recorder.Flushed)
+	assert.Nil(t, w.FlushError())
+	assert.Equal(t, recorder, w.Unwrap())
+}
@@ -2,6 +2,7 @@ package gee
 
 import (
 	"net/http"
+	"sort"
 	"strings"
 )
 
@@ -18,7 +19,8 @@ func newRouter() *router {
 	}
 }
 
-func (r *router) addRouter(method string, pattern string, handler HandlerFunc) {
+// addRouter 添加路由，middlewares 为只作用于该路由的中间件
+func (r *router) addRouter(method string, pattern string, handler HandlerFunc, middlewares ...HandlerFunc) {
 	assert1(method != "", "HTTP method can not be empty")
 	assert1(pattern[0] == '/', "Path must begin with '/'")
 	assert1(handler != nil, "Handler can not be nil")
@@ -31,7 +33,8 @@ func (r *router) addRouter(method string, pattern string, handler HandlerFunc) {
 		r.roots[method] = root
 	}
 
-	root.insert(pattern, parts, 0)
+	n := root.insert(pattern, parts, 0)
+	n.middlewares = middlewares
 	r.handlers[key] = handler
 }
 
@@ -70,10 +73,43 @@ func (r *router) handle(c *Context) {
 		handler := r.handlers[key]
 		c.middlewares = append(c.middlewares, n.middlewares...)
 		c.middlewares = append(c.middlewares, handler)
+	} else if allowed := r.methodNotAllowed(c); len(allowed) > 0 {
+		c.middlewares = append(c.middlewares, func(c *Context) {
+			c.SetHeader("Allow", strings.Join(allowed, ", "))
+			if c.engine.UseProblemDetails {
+				detail := "Method " + c.Method + " is not allowed for " + c.Path
+				c.Problem(http.StatusMethodNotAllowed, NewProblem(http.StatusMethodNotAllowed, detail))
+				return
+			}
+			c.String(http.StatusMethodNotAllowed, "405 METHOD NOT ALLOWED: %s\n", c.Path)
+		})
 	} else {
 		c.middlewares = append(c.middlewares, func(c *Context) {
+			if c.engine.UseProblemDetails {
+				c.Problem(http.StatusNotFound, NewProblem(http.StatusNotFound, "No route for "+c.Path))
+				return
+			}
 			c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
 		})
 	}
 	c.Next()
 }
+
+// methodNotAllowed 开启 Engine.HandleMethodNotAllowed 时，返回能匹配当前路径的其他请求方法
+func (r *router) methodNotAllowed(c *Context) []string {
+	if !c.engine.HandleMethodNotAllowed {
+		return nil
+	}
+
+	var allowed []string
+	for m := range r.roots {
+		if m == c.Method {
+			continue
+		}
+		if n, _ := r.getRouter(m, c.Path); n != nil {
+			allowed = append(allowed, m)
+		}
+	}
+	sort.Strings(allowed)
+	return allowed
+}
@@ -25,6 +25,7 @@ type Router interface {
 	Trace(string, HandlerFunc)
 	Patch(string, HandlerFunc)
 	Any(string, HandlerFunc)
+	Handle(string, string, ...HandlerFunc)
 
 	Static(string, string)
 }
@@ -51,10 +52,18 @@ func (group *RouterGroup) Use(middlewares ...HandlerFunc) {
 	group.middlewares = append(group.middlewares, middlewares...)
 }
 
-func (group *RouterGroup) addRouter(method string, comp string, handler HandlerFunc) {
+func (group *RouterGroup) addRouter(method string, comp string, handler HandlerFunc, middlewares ...HandlerFunc) {
 	pattern := group.prefix + comp
 	log.Printf("%-7s - %s\n", method, pattern)
-	group.engine.router.addRouter(method, pattern, handler)
+	group.engine.router.addRouter(method, pattern, handler, middlewares...)
+}
+
+// Handle 注册路由，handlers 的最后一个为处理函数，其余为只作用于该路由的中间件，
+// 如：v1.Handle(http.MethodGet, "/export", gee.Buffered(0), etag, export)
+func (group *RouterGroup) Handle(method string, pattern string, handlers ...HandlerFunc) {
+	assert1(len(handlers) > 0, "Handler can not be nil")
+	n := len(handlers)
+	group.addRouter(method, pattern, handlers[n-1], handlers[:n-1]...)
 }
 
 func (group *RouterGroup) GET(pattern string, handler HandlerFunc) {
@@ -47,11 +47,12 @@ func (n *node) matchChildren(part string) []*node {
 	return nodes
 }
 
-func (n *node) insert(pattern string, parts []string, height int) {
+// insert 插入路由，返回路由对应的终结点
+func (n *node) insert(pattern string, parts []string, height int) *node {
 	if len(parts) == height {
 		n.pattern = pattern
 		n.isEnd = true
-		return
+		return n
 	}
 
 	part := parts[height]
@@ -70,7 +71,7 @@ func (n *node) insert(pattern string, parts []string, height int) {
 		n.childHasEnd = true
 	}
 
-	child.insert(pattern, parts, height+1)
+	return child.insert(pattern, parts, height+1)
 }
 
 func (n *node) search(parts []string, height int) *node {